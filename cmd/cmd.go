@@ -5,11 +5,14 @@ import (
 	"github.com/bwmarrin/discordgo"
 	"github.com/floriansw/go-discordgo-utils/handler"
 	"github.com/floriansw/hll-discord-server-watcher/internal"
+	"github.com/floriansw/hll-discord-server-watcher/internal/backup"
 	"github.com/floriansw/hll-discord-server-watcher/internal/commands"
+	"github.com/floriansw/hll-discord-server-watcher/internal/watcher"
 	"github.com/floriansw/hll-discord-server-watcher/resources"
 	"log/slog"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 )
 
@@ -23,7 +26,7 @@ func main() {
 
 	c, err := internal.NewConfig("./config.json", logger)
 	if err != nil {
-		logger.Error("config", err)
+		logger.Error("config", "error", err)
 		return
 	}
 
@@ -31,47 +34,86 @@ func main() {
 	if c.Discord != nil {
 		s, err = discordgo.New("Bot " + c.Discord.Token)
 		if err != nil {
-			logger.Error("discord", err)
+			logger.Error("discord", "error", err)
 			return
 		}
 	}
 	for _, d := range []string{"servers", "templates"} {
 		if err = os.MkdirAll(fmt.Sprintf("./%s/", d), 0644); err != nil {
-			logger.Error("create-matches", err)
+			logger.Error("create-matches", "error", err)
 			return
 		}
 	}
-	servers := resources.NewServers("./servers/")
-	templates := resources.NewTemplates("./templates/")
+	serverFiles, templateFiles := resources.NewServers("./servers/"), resources.NewTemplates("./templates/")
+	servers := internal.NewCachedRepository(serverFiles)
+	templates := internal.NewCachedRepository(templateFiles)
+	backups := backup.New(logger, c, serverFiles, templateFiles)
+	if len(os.Args) == 3 && os.Args[1] == "restore" {
+		if err := backups.Restore(os.Args[2]); err != nil {
+			logger.Error("restore-backup", "error", err)
+			os.Exit(1)
+		}
+		logger.Info("restored-backup", "file", os.Args[2])
+		return
+	}
+	driftCheck := commands.NewDriftCheck(logger, c, servers, templates)
 	h := handler.New(logger, s, c.Discord.GuildID, map[string]interface{}{
 		"create-embed":     commands.NewCreateEmbedCommand(logger, c, servers),
 		"add-server":       commands.NewAddServerCommand(logger, c, servers),
 		"credentials":      commands.NewCredentialsCommand(logger, c, servers),
 		"add-template":     commands.NewAddTemplateCommand(logger, c, templates),
-		"template":         commands.NewTemplatesCommand(logger, c, templates),
-		"add-broadcast":    commands.NewAddBroadcastMessageCommand(logger, c, templates),
-		"delete-broadcast": commands.NewDeleteBroadcastMessageCommand(logger, c, templates),
+		"template":         commands.NewTemplatesCommand(logger, c, templates, servers),
+		"add-broadcast":    commands.NewAddBroadcastMessageCommand(logger, c, templates, servers),
+		"delete-broadcast": commands.NewDeleteBroadcastMessageCommand(logger, c, templates, servers),
+		"template-export":  commands.NewExportTemplateCommand(logger, c, templates),
+		"template-import":  commands.NewImportTemplateCommand(logger, c, templates),
+		"backup":           commands.NewBackupCommand(logger, c, backups),
+		"diagnose":         commands.NewDiagnoseCommand(logger, c, servers),
 		"embeds":           commands.NewEmbedCommand(logger, c, servers, templates),
+		"drift":            driftCheck,
 	})
 	if s != nil {
 		s.AddHandlerOnce(func(s *discordgo.Session, e *discordgo.Ready) {
 			if err := h.Listen(); err != nil {
-				logger.Error("discord-listen", err)
+				logger.Error("discord-listen", "error", err)
 				panic(err)
 			}
 			logger.Info("ready")
 		})
 		err = s.Open()
 		if err != nil {
-			logger.Error("open-session", err)
+			logger.Error("open-session", "error", err)
 			return
 		}
 		defer s.Close()
 	}
 
+	done := make(chan struct{})
+	go backups.Schedule(done)
+	go commands.NewHealthCheck(logger, c, servers).Schedule(s, done)
+	go driftCheck.Schedule(s, done)
+
+	w := watcher.New(logger, "./servers/", serverFiles, "./templates/", templateFiles)
+	w.OnChange(func(changes []watcher.Change) {
+		servers.Invalidate()
+		templates.Invalidate()
+		if s == nil || !slices.ContainsFunc(changes, func(c watcher.Change) bool { return c.Kind == watcher.KindServer && c.Err == nil }) {
+			return
+		}
+		if err := commands.RefreshEmbed(s, c, servers); err != nil {
+			logger.Error("refresh-embed", "error", err)
+		}
+	})
+	go func() {
+		if err := w.Watch(done); err != nil {
+			logger.Error("watch-resources", "error", err)
+		}
+	}()
+
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 	<-stop
+	close(done)
 
 	logger.Info("graceful-shutdown")
 	if err := c.Save(); err != nil {
@@ -7,13 +7,13 @@ require (
 	github.com/floriansw/go-crcon v0.0.0-20250401152855-226f4d462c61
 	github.com/floriansw/go-discordgo-utils v0.0.0-20250324210312-73a604af0b0b
 	github.com/floriansw/go-tcadmin v0.0.0-20250320214444-adf04509b951
+	github.com/fsnotify/fsnotify v1.8.0
 	github.com/google/uuid v1.6.0
 	github.com/onsi/ginkgo v1.16.5
 	github.com/onsi/gomega v1.36.2
 )
 
 require (
-	github.com/fsnotify/fsnotify v1.8.0 // indirect
 	github.com/google/go-cmp v0.7.0 // indirect
 	github.com/gorilla/websocket v1.5.3 // indirect
 	github.com/nxadm/tail v1.4.11 // indirect
@@ -0,0 +1,453 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/floriansw/hll-discord-server-watcher/internal"
+	"github.com/floriansw/hll-discord-server-watcher/resources"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	defaultDirectory = "./backups/"
+	filePrefix       = "backup-"
+	timeFormat       = "20060102-150405"
+
+	manifestFile = "manifest.json"
+	configFile   = "config.json"
+	serversDir   = "servers/"
+	templatesDir = "templates/"
+)
+
+var (
+	ErrEncryptionKeyMissing = errors.New("the backup is encrypted, but no encryption key is configured")
+)
+
+type manifest struct {
+	CreatedAt           time.Time `json:"created_at"`
+	CredentialsExcluded bool      `json:"credentials_excluded"`
+}
+
+type Backups struct {
+	logger    *slog.Logger
+	config    *internal.Config
+	servers   internal.Storage[resources.Server]
+	templates internal.Storage[resources.Template]
+}
+
+func New(l *slog.Logger, c *internal.Config, s internal.Storage[resources.Server], t internal.Storage[resources.Template]) *Backups {
+	return &Backups{
+		logger:    l,
+		config:    c,
+		servers:   s,
+		templates: t,
+	}
+}
+
+func (b *Backups) settings() internal.Backup {
+	s := internal.Backup{}
+	if b.config.Backup != nil {
+		s = *b.config.Backup
+	}
+	if s.Directory == "" {
+		s.Directory = defaultDirectory
+	}
+	return s
+}
+
+// Schedule creates a backup every configured interval until stop is closed. It returns immediately when scheduled
+// backups are disabled.
+func (b *Backups) Schedule(stop <-chan struct{}) {
+	s := b.settings()
+	if s.IntervalHours <= 0 {
+		return
+	}
+	t := time.NewTicker(time.Duration(s.IntervalHours) * time.Hour)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+			if p, err := b.Create(); err != nil {
+				b.logger.Error("scheduled-backup", "error", err)
+			} else {
+				b.logger.Info("scheduled-backup", "file", p)
+			}
+		}
+	}
+}
+
+// Create writes a new timestamped backup archive and removes archives exceeding the configured retention. It returns
+// the path of the created archive.
+func (b *Backups) Create() (string, error) {
+	s := b.settings()
+	excludeCredentials := s.ExcludeCredentials || s.EncryptionKey == ""
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	now := time.Now().UTC()
+	if err := writeJson(tw, manifestFile, manifest{CreatedAt: now, CredentialsExcluded: excludeCredentials}); err != nil {
+		return "", err
+	}
+	if err := writeJson(tw, configFile, b.archivedConfig(excludeCredentials)); err != nil {
+		return "", err
+	}
+	servers, err := listAll(b.servers)
+	if err != nil {
+		return "", err
+	}
+	for _, server := range servers {
+		if excludeCredentials {
+			server.CRConCredentials = nil
+			server.RConCredentials = nil
+			server.TCAdminCredentials = nil
+			server.PterodactylCredentials = nil
+		}
+		if err := writeJson(tw, serversDir+server.Id(), server); err != nil {
+			return "", err
+		}
+	}
+	templates, err := listAll(b.templates)
+	if err != nil {
+		return "", err
+	}
+	for _, template := range templates {
+		if err := writeJson(tw, templatesDir+template.Id(), template); err != nil {
+			return "", err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return "", err
+	}
+	if err := gw.Close(); err != nil {
+		return "", err
+	}
+
+	content := buf.Bytes()
+	name := filePrefix + now.Format(timeFormat) + ".tar.gz"
+	if s.EncryptionKey != "" {
+		content, err = encrypt(s.EncryptionKey, content)
+		if err != nil {
+			return "", err
+		}
+		name += ".enc"
+	}
+	if err := os.MkdirAll(s.Directory, 0755); err != nil {
+		return "", err
+	}
+	p := path.Join(s.Directory, name)
+	if err := os.WriteFile(p, content, 0600); err != nil {
+		return "", err
+	}
+	return p, b.cleanup(s)
+}
+
+func (b *Backups) archivedConfig(excludeCredentials bool) internal.Config {
+	c := *b.config
+	if c.Discord != nil && excludeCredentials {
+		d := *c.Discord
+		d.Token = ""
+		c.Discord = &d
+	}
+	if c.Backup != nil {
+		bc := *c.Backup
+		bc.EncryptionKey = ""
+		c.Backup = &bc
+	}
+	return c
+}
+
+func (b *Backups) cleanup(s internal.Backup) error {
+	if s.Retention <= 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(s.Directory)
+	if err != nil {
+		return err
+	}
+	var archives []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasPrefix(entry.Name(), filePrefix) {
+			archives = append(archives, entry.Name())
+		}
+	}
+	// the timestamp in the file name sorts chronologically
+	slices.Sort(archives)
+	for len(archives) > s.Retention {
+		if err := os.Remove(path.Join(s.Directory, archives[0])); err != nil {
+			return err
+		}
+		b.logger.Info("delete-old-backup", "file", archives[0])
+		archives = archives[1:]
+	}
+	return nil
+}
+
+type archive struct {
+	manifest  *manifest
+	config    *internal.Config
+	servers   []resources.Server
+	templates []resources.Template
+}
+
+// Restore replaces the current config, servers and templates with the content of the archive at the given path. The
+// whole archive is validated before any data is replaced. Credentials and the Discord token are kept from the current
+// data when the archive was created without them.
+func (b *Backups) Restore(p string) error {
+	content, err := os.ReadFile(p)
+	if err != nil {
+		return err
+	}
+	a, err := b.read(content)
+	if err != nil {
+		return fmt.Errorf("invalid backup archive: %w", err)
+	}
+
+	current, err := listAll(b.servers)
+	if err != nil {
+		return err
+	}
+	if a.manifest.CredentialsExcluded {
+		for idx := range a.servers {
+			keepCredentials(&a.servers[idx], current)
+		}
+	}
+	// all entities are written before anything is deleted, so that a failing save does not leave the store without
+	// servers or templates
+	if err := saveAll(b.servers, a.servers); err != nil {
+		return partialRestore(err)
+	}
+	if err := saveAll(b.templates, a.templates); err != nil {
+		return partialRestore(err)
+	}
+	if err := deleteOthers(b.servers, a.servers); err != nil {
+		return partialRestore(err)
+	}
+	if err := deleteOthers(b.templates, a.templates); err != nil {
+		return partialRestore(err)
+	}
+
+	if a.config.Discord != nil && a.config.Discord.Token == "" && b.config.Discord != nil {
+		a.config.Discord.Token = b.config.Discord.Token
+	}
+	if b.config.Backup != nil {
+		if a.config.Backup == nil {
+			a.config.Backup = &internal.Backup{}
+		}
+		a.config.Backup.EncryptionKey = b.config.Backup.EncryptionKey
+	}
+	b.config.Replace(*a.config)
+	if err := b.config.Save(); err != nil {
+		return partialRestore(err)
+	}
+	return nil
+}
+
+func partialRestore(err error) error {
+	return fmt.Errorf("the restore is incomplete, servers, templates and the config might be partially restored, restore the archive again: %w", err)
+}
+
+func saveAll[T resources.Identifiable](s internal.Storage[T], entities []T) error {
+	for _, e := range entities {
+		if err := s.Save(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteOthers deletes the stored entities which are not part of the given ones.
+func deleteOthers[T resources.Identifiable](s internal.Storage[T], entities []T) error {
+	keep := map[string]bool{}
+	for _, e := range entities {
+		keep[e.Id()] = true
+	}
+	ids, err := s.List()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if keep[id.String()] {
+			continue
+		}
+		if err := s.Delete(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func keepCredentials(server *resources.Server, current []resources.Server) {
+	for _, c := range current {
+		if c.ServerId != server.ServerId {
+			continue
+		}
+		if server.CRConCredentials == nil {
+			server.CRConCredentials = c.CRConCredentials
+		}
+		if server.RConCredentials == nil {
+			server.RConCredentials = c.RConCredentials
+		}
+		if server.TCAdminCredentials == nil {
+			server.TCAdminCredentials = c.TCAdminCredentials
+		}
+		if server.PterodactylCredentials == nil {
+			server.PterodactylCredentials = c.PterodactylCredentials
+		}
+	}
+}
+
+func (b *Backups) read(content []byte) (*archive, error) {
+	if !bytes.HasPrefix(content, []byte{0x1f, 0x8b}) {
+		key := b.settings().EncryptionKey
+		if key == "" {
+			return nil, ErrEncryptionKeyMissing
+		}
+		var err error
+		content, err = decrypt(key, content)
+		if err != nil {
+			return nil, err
+		}
+	}
+	gr, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(gr)
+	a := &archive{}
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		c, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case h.Name == manifestFile:
+			a.manifest = &manifest{}
+			err = json.Unmarshal(c, a.manifest)
+		case h.Name == configFile:
+			a.config = &internal.Config{}
+			err = json.Unmarshal(c, a.config)
+		case strings.HasPrefix(h.Name, serversDir):
+			var s resources.Server
+			if err = json.Unmarshal(c, &s); err == nil && s.Id() != strings.TrimPrefix(h.Name, serversDir) {
+				err = errors.New("server ID does not match the file name")
+			} else if err == nil {
+				_, err = resources.ParseId(s.Id())
+			}
+			a.servers = append(a.servers, s)
+		case strings.HasPrefix(h.Name, templatesDir):
+			var t resources.Template
+			if err = json.Unmarshal(c, &t); err == nil && t.Id() != strings.TrimPrefix(h.Name, templatesDir) {
+				err = errors.New("template ID does not match the file name")
+			} else if err == nil {
+				err = t.Validate()
+			}
+			a.templates = append(a.templates, t)
+		default:
+			err = errors.New("unexpected file")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", h.Name, err)
+		}
+	}
+	if a.manifest == nil {
+		return nil, errors.New(manifestFile + " is missing")
+	}
+	if a.config == nil {
+		return nil, errors.New(configFile + " is missing")
+	}
+	return a, nil
+}
+
+func listAll[T resources.Identifiable](s internal.Storage[T]) (res []T, err error) {
+	ids, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		e, err := s.Find(id)
+		if err != nil {
+			return nil, err
+		}
+		if e != nil {
+			res = append(res, *e)
+		}
+	}
+	return
+}
+
+func writeJson(tw *tar.Writer, name string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(b)),
+		ModTime: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = tw.Write(b)
+	return err
+}
+
+func aead(key string) (cipher.AEAD, error) {
+	k := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(k[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func encrypt(key string, content []byte) ([]byte, error) {
+	gcm, err := aead(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, content, nil), nil
+}
+
+func decrypt(key string, content []byte) ([]byte, error) {
+	gcm, err := aead(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(content) < gcm.NonceSize() {
+		return nil, errors.New("encrypted content too short")
+	}
+	res, err := gcm.Open(nil, content[:gcm.NonceSize()], content[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.New("could not decrypt backup, the encryption key might be wrong")
+	}
+	return res, nil
+}
@@ -0,0 +1,183 @@
+package backup_test
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/floriansw/hll-discord-server-watcher/internal"
+	"github.com/floriansw/hll-discord-server-watcher/internal/backup"
+	"github.com/floriansw/hll-discord-server-watcher/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"log/slog"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+)
+
+var _ = Describe("Backups", func() {
+	var (
+		dir       string
+		config    *internal.Config
+		servers   internal.Storage[resources.Server]
+		templates internal.Storage[resources.Template]
+		backups   *backup.Backups
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp(os.TempDir(), "backup")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.MkdirAll(path.Join(dir, "servers"), 0755)).ToNot(HaveOccurred())
+		Expect(os.MkdirAll(path.Join(dir, "templates"), 0755)).ToNot(HaveOccurred())
+		l := slog.New(slog.NewTextHandler(os.Stdout, nil))
+		config, err = internal.NewConfig(path.Join(dir, "config.json"), l)
+		Expect(err).ToNot(HaveOccurred())
+		config.Discord = &internal.Discord{Token: "token", GuildID: "guild"}
+		config.Backup = &internal.Backup{Directory: path.Join(dir, "backups"), Retention: 2}
+		servers = resources.NewServers(path.Join(dir, "servers"))
+		templates = resources.NewTemplates(path.Join(dir, "templates"))
+		backups = backup.New(l, config, servers, templates)
+
+		Expect(servers.Save(resources.Server{
+			ServerId:         "a-server",
+			Name:             "Server",
+			CRConCredentials: &resources.CRConCredentials{BaseUrl: "https://crcon", ApiKey: "secret"},
+		})).ToNot(HaveOccurred())
+		Expect(templates.Save(resources.Template{TemplateId: "a-template", Name: "Template"})).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).ToNot(HaveOccurred())
+	})
+
+	It("restores data and keeps local credentials when they were excluded", func() {
+		p, err := backups.Create()
+		Expect(err).ToNot(HaveOccurred())
+		content, err := os.ReadFile(p)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).ToNot(ContainSubstring("secret"))
+
+		Expect(templates.Delete(mustParseId("a-template"))).ToNot(HaveOccurred())
+		Expect(templates.Save(resources.Template{TemplateId: "other", Name: "Other"})).ToNot(HaveOccurred())
+
+		Expect(backups.Restore(p)).ToNot(HaveOccurred())
+		l, err := templates.List()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(l).To(ConsistOf(mustParseId("a-template")))
+		s, err := servers.Find(mustParseId("a-server"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.CRConCredentials.ApiKey).To(Equal("secret"))
+		Expect(config.Discord.Token).To(Equal("token"))
+	})
+
+	It("keeps the current data when saving the restored data fails", func() {
+		p, err := backups.Create()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(templates.Save(resources.Template{TemplateId: "other", Name: "Other"})).ToNot(HaveOccurred())
+
+		l := slog.New(slog.NewTextHandler(os.Stdout, nil))
+		failing := backup.New(l, config, servers, failingSave[resources.Template]{templates})
+		Expect(failing.Restore(p)).To(MatchError(errSave))
+		ids, err := templates.List()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ids).To(ConsistOf(mustParseId("a-template"), mustParseId("other")))
+	})
+
+	It("reports a partial restore when deleting stale data fails", func() {
+		p, err := backups.Create()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(templates.Save(resources.Template{TemplateId: "other", Name: "Other"})).ToNot(HaveOccurred())
+
+		l := slog.New(slog.NewTextHandler(os.Stdout, nil))
+		failing := backup.New(l, config, failingDelete[resources.Server]{servers}, failingDelete[resources.Template]{templates})
+		err = failing.Restore(p)
+		Expect(err).To(MatchError(errDelete))
+		Expect(err.Error()).To(ContainSubstring("incomplete"))
+		ids, err := templates.List()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ids).To(ConsistOf(mustParseId("a-template"), mustParseId("other")))
+	})
+
+	It("restores every config field", func() {
+		config.EmbedMessage = &internal.EmbedMessage{ChannelId: "channel", MessageId: "message"}
+		config.Backup.IntervalHours = 1
+		config.Backup.ExcludeCredentials = false
+		config.Backup.EncryptionKey = "key"
+		config.PermissionStrictness = internal.StrictnessReject
+		config.HealthCheck = &internal.HealthCheck{IntervalMinutes: 5}
+		config.DriftCheck = &internal.DriftCheck{IntervalMinutes: 10}
+		config.Alerts = &internal.Alerts{ChannelId: "alerts", RoleId: "role"}
+		config.SecretRoles = []string{"admins"}
+		v := reflect.ValueOf(*config)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				Expect(v.Field(i).IsZero()).To(BeFalse(), v.Type().Field(i).Name)
+			}
+		}
+		expected, err := json.Marshal(config)
+		Expect(err).ToNot(HaveOccurred())
+
+		p, err := backups.Create()
+		Expect(err).ToNot(HaveOccurred())
+		config.Replace(internal.Config{Backup: &internal.Backup{Directory: config.Backup.Directory, EncryptionKey: "key"}})
+		Expect(backups.Restore(p)).ToNot(HaveOccurred())
+
+		Expect(json.Marshal(config)).To(MatchJSON(expected))
+		Expect(internal.NewConfig(path.Join(dir, "config.json"), slog.New(slog.DiscardHandler))).To(Equal(config))
+	})
+
+	It("encrypts the archive when a key is configured", func() {
+		config.Backup.EncryptionKey = "key"
+		p, err := backups.Create()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strings.HasSuffix(p, ".enc")).To(BeTrue())
+
+		Expect(servers.Delete(mustParseId("a-server"))).ToNot(HaveOccurred())
+		Expect(backups.Restore(p)).ToNot(HaveOccurred())
+		s, err := servers.Find(mustParseId("a-server"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.CRConCredentials.ApiKey).To(Equal("secret"))
+
+		config.Backup.EncryptionKey = "wrong"
+		Expect(backups.Restore(p)).To(HaveOccurred())
+	})
+
+	It("rejects archives with unexpected content", func() {
+		p := path.Join(dir, "invalid.tar.gz")
+		Expect(os.WriteFile(p, []byte("not an archive"), 0600)).ToNot(HaveOccurred())
+		config.Backup.EncryptionKey = "key"
+		Expect(backups.Restore(p)).To(HaveOccurred())
+		l, err := templates.List()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(l).To(ConsistOf(mustParseId("a-template")))
+	})
+})
+
+func mustParseId(v string) resources.Id {
+	id, err := resources.ParseId(v)
+	Expect(err).ToNot(HaveOccurred())
+	return id
+}
+
+var errSave = errors.New("save failed")
+
+// failingSave is a storage which can not save entities.
+type failingSave[T resources.Identifiable] struct {
+	internal.Storage[T]
+}
+
+func (failingSave[T]) Save(T) error {
+	return errSave
+}
+
+var errDelete = errors.New("delete failed")
+
+// failingDelete is a storage which can not delete entities.
+type failingDelete[T resources.Identifiable] struct {
+	internal.Storage[T]
+}
+
+func (failingDelete[T]) Delete(resources.Id) error {
+	return errDelete
+}
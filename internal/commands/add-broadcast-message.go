@@ -18,14 +18,16 @@ type addBroadcastMessageData struct {
 type AddBroadcastMessageCommand struct {
 	logger    *slog.Logger
 	config    *internal.Config
-	templates internal.Storage[resources.Template]
+	templates internal.Repository[resources.Template]
+	servers   internal.Repository[resources.Server]
 }
 
-func NewAddBroadcastMessageCommand(l *slog.Logger, c *internal.Config, m internal.Storage[resources.Template]) *AddBroadcastMessageCommand {
+func NewAddBroadcastMessageCommand(l *slog.Logger, c *internal.Config, m internal.Repository[resources.Template], servers internal.Repository[resources.Server]) *AddBroadcastMessageCommand {
 	return &AddBroadcastMessageCommand{
 		logger:    l,
 		config:    c,
 		templates: m,
+		servers:   servers,
 	}
 }
 
@@ -58,29 +60,16 @@ func (c *AddBroadcastMessageCommand) Definition(cmd string) *discordgo.Applicati
 }
 
 func (c *AddBroadcastMessageCommand) OnAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	l, err := c.templates.List()
+	l, err := c.templates.Search(focusedValue(i))
 	if err != nil {
 		c.logger.Error("list-templates", "error", err)
-		ErrorResponse(s, i.Interaction, "Could not list templates. Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "Could not list templates.", err)
 		return
 	}
-	var choices []*discordgo.ApplicationCommandOptionChoice
-	for _, tid := range l {
-		tpl, err := c.templates.Find(tid)
-		if err != nil {
-			c.logger.Error("find-templates", "error", err)
-			ErrorResponse(s, i.Interaction, "Could not find template with ID "+tid+". Error: "+err.Error())
-			continue
-		}
-		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
-			Name:  tpl.Name,
-			Value: tpl.TemplateId,
-		})
-	}
 	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
 		Data: &discordgo.InteractionResponseData{
-			Choices: choices,
+			Choices: autocompleteChoices(l),
 		},
 	})
 	if err != nil {
@@ -106,7 +95,7 @@ func (c *AddBroadcastMessageCommand) OnCommand(s *discordgo.Session, i *discordg
 	tpl, err := c.templates.Find(d.TemplateId)
 	if err != nil {
 		c.logger.Error("find-template", "error", err)
-		ErrorResponse(s, i.Interaction, "There was an error fetching template details. Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "There was an error fetching template details.", err)
 		return
 	}
 	if tpl == nil {
@@ -117,14 +106,23 @@ func (c *AddBroadcastMessageCommand) OnCommand(s *discordgo.Session, i *discordg
 		Time:    d.Time,
 		Message: d.Message,
 	})
+	tpl.Version++
 	err = c.templates.Save(*tpl)
 	if err != nil {
 		c.logger.Error("save-tpl", "error", err)
-		ErrorResponse(s, i.Interaction, "There was an error saving the template. Please try again. Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "There was an error saving the template. Please try again.", err)
 		return
 	}
+	message := "The message was added to the template."
+	users, err := templateUsers(c.templates, c.servers, tpl.TemplateId)
+	if err != nil {
+		c.logger.Error("list-template-users", "error", err)
+	}
+	if w := usageWarning(users); w != "" {
+		message += "\n\n" + w
+	}
 	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-		Content: String("The message was added to the template."),
+		Content: &message,
 	})
 	if err != nil {
 		c.logger.Error("edit-response", "error", err)
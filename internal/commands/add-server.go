@@ -18,10 +18,10 @@ type addServerData struct {
 type AddServerCommand struct {
 	logger  *slog.Logger
 	config  *internal.Config
-	servers internal.Storage[resources.Server]
+	servers internal.Repository[resources.Server]
 }
 
-func NewAddServerCommand(l *slog.Logger, c *internal.Config, m internal.Storage[resources.Server]) *AddServerCommand {
+func NewAddServerCommand(l *slog.Logger, c *internal.Config, m internal.Repository[resources.Server]) *AddServerCommand {
 	return &AddServerCommand{
 		logger:  l,
 		config:  c,
@@ -65,7 +65,7 @@ func (c *AddServerCommand) OnCommand(s *discordgo.Session, i *discordgo.Interact
 	err := c.servers.Save(server)
 	if err != nil {
 		c.logger.Error("save-server", "error", err)
-		ErrorResponse(s, i.Interaction, "There was an error saving the server. Please try again. Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "There was an error saving the server. Please try again.", err)
 		return
 	}
 	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
@@ -18,10 +18,10 @@ type addTemplateData struct {
 type AddTemplateCommand struct {
 	logger    *slog.Logger
 	config    *internal.Config
-	templates internal.Storage[resources.Template]
+	templates internal.Repository[resources.Template]
 }
 
-func NewAddTemplateCommand(l *slog.Logger, c *internal.Config, m internal.Storage[resources.Template]) *AddTemplateCommand {
+func NewAddTemplateCommand(l *slog.Logger, c *internal.Config, m internal.Repository[resources.Template]) *AddTemplateCommand {
 	return &AddTemplateCommand{
 		logger:    l,
 		config:    c,
@@ -61,11 +61,12 @@ func (c *AddTemplateCommand) OnCommand(s *discordgo.Session, i *discordgo.Intera
 	tpl := resources.Template{
 		TemplateId: uuid.NewString(),
 		Name:       d.Name,
+		Version:    1,
 	}
 	err := c.templates.Save(tpl)
 	if err != nil {
 		c.logger.Error("save-tpl", "error", err)
-		ErrorResponse(s, i.Interaction, "There was an error saving the template. Please try again. Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "There was an error saving the template. Please try again.", err)
 		return
 	}
 	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
@@ -0,0 +1,27 @@
+package commands
+
+import (
+	"errors"
+	"github.com/bwmarrin/discordgo"
+	"github.com/floriansw/hll-discord-server-watcher/internal"
+)
+
+var errNoAlertChannel = errors.New("no alert channel configured")
+
+// sendAlert posts the embeds to the configured alert channel and mentions the alert role, if one is configured.
+func sendAlert(s *discordgo.Session, c *internal.Config, embeds []*discordgo.MessageEmbed, components []discordgo.MessageComponent) error {
+	if s == nil || c.Alerts == nil || c.Alerts.ChannelId == "" {
+		return errNoAlertChannel
+	}
+	m := &discordgo.MessageSend{
+		Embeds:          embeds,
+		Components:      components,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	}
+	if c.Alerts.RoleId != "" {
+		m.Content = "<@&" + c.Alerts.RoleId + ">"
+		m.AllowedMentions.Roles = []string{c.Alerts.RoleId}
+	}
+	_, err := s.ChannelMessageSendComplex(c.Alerts.ChannelId, m)
+	return err
+}
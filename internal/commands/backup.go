@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	. "github.com/floriansw/go-discordgo-utils/util"
+	"github.com/floriansw/hll-discord-server-watcher/internal"
+	"github.com/floriansw/hll-discord-server-watcher/internal/backup"
+	"log/slog"
+)
+
+type BackupCommand struct {
+	logger  *slog.Logger
+	config  *internal.Config
+	backups *backup.Backups
+}
+
+func NewBackupCommand(l *slog.Logger, c *internal.Config, b *backup.Backups) *BackupCommand {
+	return &BackupCommand{
+		logger:  l,
+		config:  c,
+		backups: b,
+	}
+}
+
+func (c *BackupCommand) Definition(cmd string) *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        cmd,
+		Description: "Creates a backup of the configuration, servers and templates",
+	}
+}
+
+func (c *BackupCommand) OnCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+
+	p, err := c.backups.Create()
+	if err != nil {
+		c.logger.Error("create-backup", "error", err)
+		ErrorResponse(s, i.Interaction, "There was an error creating the backup. Error: "+err.Error())
+		return
+	}
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: String(fmt.Sprintf("The backup was created as `%s`.", p)),
+	})
+	if err != nil {
+		c.logger.Error("edit-response", "error", err)
+		return
+	}
+}
@@ -0,0 +1,154 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	. "github.com/floriansw/go-discordgo-utils/util"
+	"github.com/floriansw/hll-discord-server-watcher/internal/hosting"
+	"github.com/floriansw/hll-discord-server-watcher/internal/rcon"
+	"github.com/floriansw/hll-discord-server-watcher/internal/resilience"
+	"github.com/floriansw/hll-discord-server-watcher/resources"
+	"github.com/google/uuid"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// liveSettings reads the current settings of the server into a template. Settings which can not be read are left
+// empty and returned in unread by their name, only failing to read the server settings is an error.
+func liveSettings(ctx context.Context, s resources.Server) (tpl resources.Template, unread map[string]error, err error) {
+	cc := crconClient(s)
+	settings, err := cc.ServerSettings(ctx)
+	if err != nil {
+		return tpl, nil, fmt.Errorf("reading server settings: %w", err)
+	}
+	tpl.TeamSwitchCooldown = Int(settings.TeamSwitchCooldown)
+	tpl.AutoBalanceThreshold = Int(settings.AutoBalanceThreshold)
+	tpl.AutoBalanceEnabled = Bool(settings.AutoBalanceEnabled)
+	tpl.IdleAutoKickTime = Int(settings.IdleAutoKickTime)
+	tpl.MaxPingAutoKick = Int(settings.MaxPingAutoKick)
+	tpl.QueueLength = Int(settings.QueueLength)
+	tpl.VipSlots = Int(settings.VipSlotsNumber)
+	tpl.VoteKickEnabled = Bool(settings.VoteKickEnabled)
+
+	unread = map[string]error{}
+	failed := func(name string, err error) bool {
+		if err != nil {
+			unread[name] = err
+		}
+		return err != nil
+	}
+	if welcome, err := cc.WelcomeMessage(ctx); !failed(resources.SettingWelcomeMessage, err) {
+		tpl.WelcomeMessage = welcome
+	}
+	if config, err := cc.AutoBroadcastConfig(ctx); !failed(resources.SettingBroadcastMessages, err) && config.Enabled {
+		for _, m := range config.Messages {
+			tpl.BroadcastMessage = append(tpl.BroadcastMessage, resources.BroadcastMessage{Time: m.TimeSec, Message: m.Message})
+		}
+	}
+	if profanities, err := cc.Profanities(ctx); !failed(resources.SettingProfanityFilter, err) {
+		tpl.ProfanityFilter = profanities
+	}
+	if rotation, err := cc.MapRotation(ctx); !failed(resources.SettingMapRotation, err) {
+		for _, m := range rotation {
+			tpl.MapRotation = append(tpl.MapRotation, fromCRConMap(m))
+		}
+	}
+	if s.HostingConfigured() {
+		hc, err := hostingClient(s)
+		if err == nil {
+			var si *hosting.ServerInfo
+			if si, err = hc.ServerInfo(ctx); err == nil {
+				tpl.ServerNameTemplate = si.Name
+			}
+		}
+		failed(resources.SettingServerName, err)
+	} else {
+		unread[resources.SettingServerName] = errors.New("no hosting provider credentials are set")
+	}
+	return tpl, unread, nil
+}
+
+// captureTemplate creates a new template from the current settings of the server. The notes name the settings which
+// could not be read and are left empty in the template. The current server name is only kept as the server name
+// template when the name of the server can be replaced with {server}, so that applying the template to another server
+// does not copy the name of this one.
+func captureTemplate(ctx context.Context, s resources.Server) (tpl resources.Template, notes []string, err error) {
+	tpl, unread, err := liveSettings(ctx, s)
+	if err != nil {
+		return tpl, nil, err
+	}
+	tpl.TemplateId = uuid.NewString()
+	tpl.Version = 1
+	tpl.Name = fmt.Sprintf("%s (captured %s)", s.Name, time.Now().Format(time.DateOnly))
+	if current := tpl.ServerNameTemplate; current != "" {
+		var ok bool
+		if tpl.ServerNameTemplate, ok = s.NameTemplateFrom(current); ok {
+			notes = append(notes, fmt.Sprintf("The server name was captured as `%s`, {server} is replaced with the name of the server the template is applied to.", tpl.ServerNameTemplate))
+		} else {
+			notes = append(notes, fmt.Sprintf("The server name `%s` was not captured, as it does not contain the name of the server. Set a server name template with placeholders like {server} and {index} instead.", current))
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(unread)) {
+		if errors.Is(unread[name], rcon.ErrUnsupported) {
+			notes = append(notes, name+" can not be read through RCON, please set it in the template.")
+		} else {
+			notes = append(notes, name+" could not be read: "+unread[name].Error())
+		}
+	}
+	return tpl, notes, nil
+}
+
+func (c *EmbedCommand) onCaptureTemplate(s *discordgo.Session, i *discordgo.InteractionCreate, sid string) {
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	server, err := c.servers.Find(sid)
+	if err != nil {
+		c.logger.Error("find-server", "error", err)
+		storageErrorResponse(s, i.Interaction, "Error trying to find server with ID "+sid+".", err)
+		return
+	}
+	if server == nil || !server.RConConfigured() {
+		ErrorResponse(s, i.Interaction, "Could not find server with ID "+sid+".")
+		return
+	}
+
+	ctx, cancel := operationContext(i.Interaction)
+	defer cancel()
+	tpl, notes, err := captureTemplate(ctx, *server)
+	if err != nil {
+		c.logger.Error("capture-template", "server", sid, "error", err)
+		ErrorResponse(s, i.Interaction, "Could not read the settings of the server. "+resilience.Describe(err))
+		return
+	}
+	if err := tpl.Validate(); err != nil {
+		ErrorResponse(s, i.Interaction, "The settings of the server can not be stored as a template. Error: "+err.Error())
+		return
+	}
+	if err := c.templates.Save(tpl); err != nil {
+		c.logger.Error("save-template", "error", err)
+		storageErrorResponse(s, i.Interaction, "There was an error saving the template.", err)
+		return
+	}
+
+	message := fmt.Sprintf("The current settings of **%s** were saved as the template **%s** with ID %s.", server.Name, tpl.Name, tpl.TemplateId)
+	if len(notes) != 0 {
+		message += "\n\nPlease review the captured settings:\n* " + strings.Join(notes, "\n* ")
+	}
+	embeds, components := renderTemplate(c.logger, c.templates, c.servers, &tpl)
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content:    &message,
+		Embeds:     &embeds,
+		Components: &components,
+	})
+	if err != nil {
+		c.logger.Error("edit-response", "error", err)
+	}
+}
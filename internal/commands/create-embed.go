@@ -12,12 +12,12 @@ import (
 type CreateEmbedCommand struct {
 	logger  *slog.Logger
 	config  *internal.Config
-	servers internal.Storage[resources.Server]
+	servers internal.Repository[resources.Server]
 }
 
 const createEmbedPrefix = "create-embed"
 
-func NewCreateEmbedCommand(l *slog.Logger, c *internal.Config, m internal.Storage[resources.Server]) *CreateEmbedCommand {
+func NewCreateEmbedCommand(l *slog.Logger, c *internal.Config, m internal.Repository[resources.Server]) *CreateEmbedCommand {
 	return &CreateEmbedCommand{
 		logger:  l,
 		config:  c,
@@ -159,6 +159,25 @@ func (c *CreateEmbedCommand) onRefresh(s *discordgo.Session, i *discordgo.Intera
 	}
 }
 
+// RefreshEmbed updates the servers embed registered in the config with the current list of servers. It does nothing
+// when no embed was created yet.
+func RefreshEmbed(s *discordgo.Session, c *internal.Config, servers internal.Repository[resources.Server]) error {
+	if c.EmbedMessage == nil {
+		return nil
+	}
+	embeds, components, err := serversEmbed(servers)
+	if err != nil {
+		return err
+	}
+	_, err = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         c.EmbedMessage.MessageId,
+		Channel:    c.EmbedMessage.ChannelId,
+		Embeds:     &embeds,
+		Components: &components,
+	})
+	return err
+}
+
 func (c *CreateEmbedCommand) OnMessageComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	id := i.MessageComponentData().CustomID
 	if id == customId(createEmbedPrefix, "confirm-recreate") {
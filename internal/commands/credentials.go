@@ -1,44 +1,24 @@
 package commands
 
 import (
-	"context"
 	"fmt"
 	"github.com/bwmarrin/discordgo"
 	"github.com/floriansw/go-discordgo-utils/marshaller"
 	. "github.com/floriansw/go-discordgo-utils/util"
 	"github.com/floriansw/hll-discord-server-watcher/internal"
+	"github.com/floriansw/hll-discord-server-watcher/internal/crconapi"
+	"github.com/floriansw/hll-discord-server-watcher/internal/hosting"
+	"github.com/floriansw/hll-discord-server-watcher/internal/resilience"
 	"github.com/floriansw/hll-discord-server-watcher/resources"
 	"log/slog"
-	"net/url"
+	"net/http"
 	"slices"
+	"strconv"
 	"strings"
 )
 
 const credentialsPrefix = "credentials"
 
-var (
-	requiredPermissions = []string{
-		"can_change_team_switch_cooldown",
-		"can_view_team_switch_cooldown",
-		"can_view_autobalance_threshold",
-		"can_view_autobalance_enabled",
-		"can_change_autobalance_enabled",
-		"can_change_autobalance_threshold",
-		"can_change_welcome_message",
-		"can_view_welcome_message",
-		"can_change_auto_broadcast_config",
-		"can_view_auto_broadcast_config",
-		"can_view_admins",
-		"can_add_admin_roles",
-		"can_remove_admin_roles",
-		"can_unban_profanities",
-		"can_view_profanities",
-		"can_ban_profanities",
-		"can_change_profanities",
-		"can_view_playerids",
-	}
-)
-
 type credentialsData struct {
 	ServerId string `discordgo:"server"`
 }
@@ -46,10 +26,10 @@ type credentialsData struct {
 type CredentialsCommand struct {
 	logger  *slog.Logger
 	config  *internal.Config
-	servers internal.Storage[resources.Server]
+	servers internal.Repository[resources.Server]
 }
 
-func NewCredentialsCommand(l *slog.Logger, c *internal.Config, m internal.Storage[resources.Server]) *CredentialsCommand {
+func NewCredentialsCommand(l *slog.Logger, c *internal.Config, m internal.Repository[resources.Server]) *CredentialsCommand {
 	return &CredentialsCommand{
 		logger:  l,
 		config:  c,
@@ -72,29 +52,16 @@ func (c *CredentialsCommand) Definition(cmd string) *discordgo.ApplicationComman
 }
 
 func (c *CredentialsCommand) OnAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	l, err := c.servers.List()
+	l, err := c.servers.Search(focusedValue(i))
 	if err != nil {
 		c.logger.Error("list-servers", "error", err)
-		ErrorResponse(s, i.Interaction, "Could not list servers. Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "Could not list servers.", err)
 		return
 	}
-	var choices []*discordgo.ApplicationCommandOptionChoice
-	for _, id := range l {
-		server, err := c.servers.Find(id)
-		if err != nil {
-			c.logger.Error("find-server", "error", err)
-			ErrorResponse(s, i.Interaction, "Could not find server with ID "+id+". Error: "+err.Error())
-			continue
-		}
-		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
-			Name:  server.Name,
-			Value: server.ServerId,
-		})
-	}
 	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
 		Data: &discordgo.InteractionResponseData{
-			Choices: choices,
+			Choices: autocompleteChoices(l),
 		},
 	})
 	if err != nil {
@@ -121,7 +88,7 @@ func (c *CredentialsCommand) OnCommand(s *discordgo.Session, i *discordgo.Intera
 	server, err := c.servers.Find(d.ServerId)
 	if err != nil {
 		c.logger.Error("find-server", "error", err)
-		ErrorResponse(s, i.Interaction, "There was an error fetching server details. Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "There was an error fetching server details.", err)
 		return
 	}
 	embeds, components := serverCredentialsEmbed(server)
@@ -138,11 +105,29 @@ func (c *CredentialsCommand) OnCommand(s *discordgo.Session, i *discordgo.Intera
 func serverCredentialsEmbed(s *resources.Server) (embeds []*discordgo.MessageEmbed, components []discordgo.MessageComponent) {
 	tcadmin := "not set"
 	if s.TCAdminCredentials != nil {
-		tcadmin = fmt.Sprintf("%s (Service ID: %s)", s.TCAdminCredentials.BaseUrl, s.TCAdminCredentials.ServiceId)
+		gameId, modId, fileId := tcadminConfigIds(*s.TCAdminCredentials)
+		tcadmin = fmt.Sprintf("%s (Service ID: %s)\nGame/Mod/File ID: %s/%s/%s\nUsername: %s\nPassword: %s\n%s", s.TCAdminCredentials.BaseUrl, s.TCAdminCredentials.ServiceId, gameId, modId, fileId, s.TCAdminCredentials.Username, maskSecret(s.TCAdminCredentials.Password), healthSummary(s.TCAdminCredentials.Health))
+	}
+	pterodactyl := "not set"
+	if c := s.PterodactylCredentials; c != nil {
+		pterodactyl = fmt.Sprintf("%s (Server ID: %s)\nAPI Key: %s\nVariables: %s, %s\n%s", c.BaseUrl, c.ServerId, maskSecret(c.ApiKey), c.NameVariable, c.PasswordVariable, healthSummary(c.Health))
+	}
+	rconCreds := "not set"
+	if c := s.RConCredentials; c != nil {
+		rconCreds = fmt.Sprintf("%s\nPassword: %s\n%s", c.Address(), maskSecret(c.Password), healthSummary(c.Health))
+		if s.CRConCredentials != nil {
+			rconCreds += "\nNot used, CRCon credentials are preferred"
+		}
+	}
+	tcadminTitle, pterodactylTitle := "TCAdmin Credentials", "Pterodactyl Credentials"
+	if s.Hosting() == resources.HostingPterodactyl {
+		pterodactylTitle += " (active)"
+	} else {
+		tcadminTitle += " (active)"
 	}
 	crcon := "not set"
 	if s.CRConCredentials != nil {
-		crcon = s.CRConCredentials.BaseUrl
+		crcon = s.CRConCredentials.BaseUrl + "\nInstance: " + crconInstance(*s.CRConCredentials) + "\nAPI Key: " + maskSecret(s.CRConCredentials.ApiKey) + "\n" + permissionSummary(s.CRConCredentials.PermissionStatus) + "\n" + healthSummary(s.CRConCredentials.Health)
 	}
 	embeds = append(embeds, &discordgo.MessageEmbed{
 		Color: ColorDarkGrey,
@@ -155,9 +140,17 @@ func serverCredentialsEmbed(s *resources.Server) (embeds []*discordgo.MessageEmb
 			Value:  crcon,
 			Inline: true,
 		}, {
-			Name:   "TCAdmin Credentials",
+			Name:   "RCON Credentials",
+			Value:  rconCreds,
+			Inline: true,
+		}, {
+			Name:   tcadminTitle,
 			Value:  tcadmin,
 			Inline: true,
+		}, {
+			Name:   pterodactylTitle,
+			Value:  pterodactyl,
+			Inline: true,
 		}},
 	})
 	components = append(components, discordgo.ActionsRow{Components: []discordgo.MessageComponent{
@@ -166,16 +159,57 @@ func serverCredentialsEmbed(s *resources.Server) (embeds []*discordgo.MessageEmb
 			CustomID: customId(credentialsPrefix, "set-crcon", s.ServerId),
 			Style:    discordgo.PrimaryButton,
 		},
+		discordgo.Button{
+			Label:    "Set RCON",
+			CustomID: customId(credentialsPrefix, "set-rcon", s.ServerId),
+			Style:    discordgo.PrimaryButton,
+		},
 		discordgo.Button{
 			Label:    "Set TCAdmin",
 			CustomID: customId(credentialsPrefix, "set-tcadmin", s.ServerId),
 			Style:    discordgo.PrimaryButton,
 		},
+		discordgo.Button{
+			Label:    "Set Pterodactyl",
+			CustomID: customId(credentialsPrefix, "set-pterodactyl", s.ServerId),
+			Style:    discordgo.PrimaryButton,
+		},
 		discordgo.Button{
 			Emoji:    &discordgo.ComponentEmoji{ID: "1283790096461594655"},
 			CustomID: customId(credentialsPrefix, "refresh", s.ServerId),
 			Style:    discordgo.SecondaryButton,
 		},
+	}}, discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		discordgo.Button{
+			Label:    "Reveal to me",
+			CustomID: customId(credentialsPrefix, "reveal", s.ServerId),
+			Style:    discordgo.SecondaryButton,
+			Disabled: !s.RConConfigured() && s.TCAdminCredentials == nil && s.PterodactylCredentials == nil,
+		},
+		discordgo.Button{
+			Label:    "Clear CRCon",
+			CustomID: customId(credentialsPrefix, "clear", "crcon", s.ServerId),
+			Style:    discordgo.DangerButton,
+			Disabled: s.CRConCredentials == nil,
+		},
+		discordgo.Button{
+			Label:    "Clear RCON",
+			CustomID: customId(credentialsPrefix, "clear", "rcon", s.ServerId),
+			Style:    discordgo.DangerButton,
+			Disabled: s.RConCredentials == nil,
+		},
+		discordgo.Button{
+			Label:    "Clear TCAdmin",
+			CustomID: customId(credentialsPrefix, "clear", "tcadmin", s.ServerId),
+			Style:    discordgo.DangerButton,
+			Disabled: s.TCAdminCredentials == nil,
+		},
+		discordgo.Button{
+			Label:    "Clear Pterodactyl",
+			CustomID: customId(credentialsPrefix, "clear", "pterodactyl", s.ServerId),
+			Style:    discordgo.DangerButton,
+			Disabled: s.PterodactylCredentials == nil,
+		},
 	}})
 	return
 }
@@ -184,7 +218,7 @@ func (c *CredentialsCommand) onSetCredentialsRefreshClick(s *discordgo.Session,
 	server, err := c.servers.Find(serverId)
 	if err != nil {
 		c.logger.Error("find-server", "error", err)
-		ErrorResponse(s, i.Interaction, "There was an error fetching server details. Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "There was an error fetching server details.", err)
 		return
 	}
 	embeds, components := serverCredentialsEmbed(server)
@@ -205,14 +239,179 @@ func (c *CredentialsCommand) OnMessageComponent(s *discordgo.Session, i *discord
 	id := i.MessageComponentData().CustomID
 	peek, _ := peekId(id)
 	if matchesId(id, customId(credentialsPrefix, "set-crcon")) {
-		c.onSetCredentialsClick(s, i, crconForm, peek)
+		c.onSetCredentialsClick(s, i, func(server *resources.Server) setCredentialsForm {
+			return crconForm(server.CRConCredentials)
+		}, peek)
+	} else if matchesId(id, customId(credentialsPrefix, "set-rcon")) {
+		c.onSetCredentialsClick(s, i, func(server *resources.Server) setCredentialsForm {
+			return rconForm(server.RConCredentials)
+		}, peek)
 	} else if matchesId(id, customId(credentialsPrefix, "set-tcadmin")) {
-		c.onSetCredentialsClick(s, i, tcadminForm, peek)
+		c.onSetCredentialsClick(s, i, func(server *resources.Server) setCredentialsForm {
+			return tcadminForm(server.TCAdminCredentials)
+		}, peek)
+	} else if matchesId(id, customId(credentialsPrefix, "set-pterodactyl")) {
+		c.onSetCredentialsClick(s, i, func(server *resources.Server) setCredentialsForm {
+			return pterodactylForm(server.PterodactylCredentials)
+		}, peek)
 	} else if matchesId(id, customId(credentialsPrefix, "refresh")) {
 		c.onSetCredentialsRefreshClick(s, i, peek)
+	} else if matchesId(id, customId(credentialsPrefix, "reveal")) {
+		c.onRevealClick(s, i, peek)
+	} else if matchesId(id, customId(credentialsPrefix, "confirm-clear")) {
+		c.onConfirmClear(s, i, peek)
+	} else if matchesId(id, customId(credentialsPrefix, "clear")) {
+		c.onClearClick(s, i, peek)
+	}
+}
+
+// credentialKinds maps the kind used in custom IDs of clear buttons to the display name of the credentials.
+var credentialKinds = map[string]string{
+	"crcon":       "CRCon",
+	"rcon":        "RCON",
+	"tcadmin":     "TCAdmin",
+	"pterodactyl": "Pterodactyl",
+}
+
+func (c *CredentialsCommand) onRevealClick(s *discordgo.Session, i *discordgo.InteractionCreate, serverId string) {
+	if !canRevealSecrets(c.config, i.Interaction) {
+		ErrorResponse(s, i.Interaction, "You are not allowed to reveal credentials.")
+		return
+	}
+	server, err := c.servers.Find(serverId)
+	if err != nil {
+		c.logger.Error("find-server", "error", err)
+		storageErrorResponse(s, i.Interaction, "There was an error fetching server details.", err)
+		return
+	}
+	if server == nil {
+		ErrorResponse(s, i.Interaction, "Could not find server with ID "+serverId)
+		return
+	}
+	var fields []*discordgo.MessageEmbedField
+	if server.CRConCredentials != nil {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  "CRCon API Key",
+			Value: "||" + server.CRConCredentials.ApiKey + "||",
+		})
+	}
+	if server.RConCredentials != nil {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  "RCON Password",
+			Value: "||" + server.RConCredentials.Password + "||",
+		})
+	}
+	if server.TCAdminCredentials != nil {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  "TCAdmin Password",
+			Value: "||" + server.TCAdminCredentials.Password + "||",
+		})
+	}
+	if server.PterodactylCredentials != nil {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  "Pterodactyl API Key",
+			Value: "||" + server.PterodactylCredentials.ApiKey + "||",
+		})
+	}
+	c.logger.Info("reveal-credentials", "server", serverId, "user", i.Member.User.ID)
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+			Embeds: []*discordgo.MessageEmbed{{
+				Title:       "Credentials of " + server.Name,
+				Description: "Only you can see this message. Do not share these secrets.",
+				Color:       ColorDarkGrey,
+				Fields:      fields,
+			}},
+		},
+	})
+	if err != nil {
+		c.logger.Error("send-response", "error", err)
 	}
 }
 
+func (c *CredentialsCommand) onClearClick(s *discordgo.Session, i *discordgo.InteractionCreate, serverId string) {
+	_, rest := peekId(i.MessageComponentData().CustomID)
+	kind, _ := peekId(rest)
+	name, ok := credentialKinds[kind]
+	if !ok {
+		ErrorResponse(s, i.Interaction, "Unknown credentials "+kind)
+		return
+	}
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags:   discordgo.MessageFlagsEphemeral,
+			Content: "Do you really want to delete the " + name + " credentials of this server? The server can not be managed until new credentials are set.",
+			Components: []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Clear " + name + " credentials",
+					CustomID: customId(credentialsPrefix, "confirm-clear", kind, serverId),
+					Style:    discordgo.DangerButton,
+				},
+			}}},
+		},
+	})
+	if err != nil {
+		c.logger.Error("send-response", "error", err)
+	}
+}
+
+func (c *CredentialsCommand) onConfirmClear(s *discordgo.Session, i *discordgo.InteractionCreate, serverId string) {
+	_, rest := peekId(i.MessageComponentData().CustomID)
+	kind, _ := peekId(rest)
+	name, ok := credentialKinds[kind]
+	if !ok {
+		ErrorResponse(s, i.Interaction, "Unknown credentials "+kind)
+		return
+	}
+	server, err := c.servers.Find(serverId)
+	if err != nil {
+		c.logger.Error("find-server", "error", err)
+		storageErrorResponse(s, i.Interaction, "There was an error fetching server details.", err)
+		return
+	}
+	if server == nil {
+		ErrorResponse(s, i.Interaction, "Could not find server with ID "+serverId)
+		return
+	}
+	switch kind {
+	case "crcon":
+		server.CRConCredentials = nil
+	case "rcon":
+		server.RConCredentials = nil
+	case "tcadmin":
+		server.TCAdminCredentials = nil
+	case "pterodactyl":
+		server.PterodactylCredentials = nil
+	}
+	if err := c.servers.Save(*server); err != nil {
+		c.logger.Error("save-server", "error", err)
+		storageErrorResponse(s, i.Interaction, "Couldn't save server data.", err)
+		return
+	}
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    name + " credentials cleared. Refresh the embed to see the new status.",
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+	if err != nil {
+		c.logger.Error("edit-response", "error", err)
+	}
+}
+
+// crconInstance describes the game server of a CRCon installation the credentials target, including its URL when it
+// differs from the base URL.
+func crconInstance(c resources.CRConCredentials) string {
+	if c.ApiUrl() == c.BaseUrl {
+		return c.Instance()
+	}
+	return c.Instance() + " (" + c.ApiUrl() + ")"
+}
+
 type setCredentialsForm struct {
 	Components []discordgo.MessageComponent
 	Title      string
@@ -220,8 +419,23 @@ type setCredentialsForm struct {
 }
 
 type setCRConFormData struct {
-	Url    string `discordgo:"crcon_url"`
-	ApiKey string `discordgo:"api_key"`
+	Url      string `discordgo:"crcon_url"`
+	ApiKey   string `discordgo:"api_key"`
+	Instance string `discordgo:"instance"`
+}
+
+type setRConFormData struct {
+	Host     string `discordgo:"host"`
+	Port     string `discordgo:"port"`
+	Password string `discordgo:"password"`
+}
+
+type setPterodactylFormData struct {
+	Url              string `discordgo:"panel_url"`
+	ServerId         string `discordgo:"server_id"`
+	ApiKey           string `discordgo:"api_key"`
+	NameVariable     string `discordgo:"name_variable"`
+	PasswordVariable string `discordgo:"password_variable"`
 }
 
 type setTCAdminFormData struct {
@@ -229,16 +443,31 @@ type setTCAdminFormData struct {
 	ServiceId string `discordgo:"service_id"`
 	Username  string `discordgo:"username"`
 	Password  string `discordgo:"password"`
+	ConfigIds string `discordgo:"config_ids"`
 }
 
-var (
-	crconForm = setCredentialsForm{
+// secretPlaceholder is shown in secret fields of modals when a value is already stored. Secrets are never sent back to
+// Discord, a blank field keeps the stored value instead when the credentials still target the same server.
+const secretPlaceholder = "Leave empty to keep the current value, as long as the target is unchanged"
+
+func crconForm(creds *resources.CRConCredentials) setCredentialsForm {
+	baseUrl, apiKeyPlaceholder, instance := "", "", ""
+	if creds != nil {
+		baseUrl, apiKeyPlaceholder = creds.BaseUrl, secretPlaceholder
+		if creds.ServerNumber > 0 {
+			instance = strconv.Itoa(creds.ServerNumber)
+		} else {
+			instance = creds.ApiPrefix
+		}
+	}
+	return setCredentialsForm{
 		Components: []discordgo.MessageComponent{
 			discordgo.ActionsRow{
 				Components: []discordgo.MessageComponent{
 					discordgo.TextInput{
 						CustomID: "crcon_url",
 						Label:    "Community RCon URL",
+						Value:    baseUrl,
 						Style:    discordgo.TextInputShort,
 						Required: true,
 					},
@@ -247,10 +476,22 @@ var (
 			discordgo.ActionsRow{
 				Components: []discordgo.MessageComponent{
 					discordgo.TextInput{
-						CustomID: "api_key",
-						Label:    "API Key",
-						Style:    discordgo.TextInputShort,
-						Required: true,
+						CustomID:    "api_key",
+						Label:       "API Key",
+						Placeholder: apiKeyPlaceholder,
+						Style:       discordgo.TextInputShort,
+						Required:    creds == nil,
+					},
+				},
+			},
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:    "instance",
+						Label:       "Server number or API prefix (optional)",
+						Placeholder: "e.g. 2 or /server2, empty for the default server",
+						Value:       instance,
+						Style:       discordgo.TextInputShort,
 					},
 				},
 			},
@@ -258,14 +499,76 @@ var (
 		Title:    "Set CRCon Credentials",
 		CustomID: customId(credentialsPrefix, "confirm-crcon"),
 	}
-	tcadminForm = setCredentialsForm{
+}
+
+func rconForm(creds *resources.RConCredentials) setCredentialsForm {
+	host, port, passwordPlaceholder := "", "", ""
+	if creds != nil {
+		host, port, passwordPlaceholder = creds.Host, strconv.Itoa(creds.Port), secretPlaceholder
+	}
+	return setCredentialsForm{
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:    "host",
+						Label:       "Host",
+						Placeholder: "IP address or host name of the game server",
+						Value:       host,
+						Style:       discordgo.TextInputShort,
+						Required:    true,
+					},
+				},
+			},
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID: "port",
+						Label:    "RCON Port",
+						Value:    port,
+						Style:    discordgo.TextInputShort,
+						Required: true,
+					},
+				},
+			},
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:    "password",
+						Label:       "RCON Password",
+						Placeholder: passwordPlaceholder,
+						Style:       discordgo.TextInputShort,
+						Required:    creds == nil,
+					},
+				},
+			},
+		},
+		Title:    "Set RCON Credentials",
+		CustomID: customId(credentialsPrefix, "confirm-rcon"),
+	}
+}
+
+func tcadminForm(creds *resources.TCAdminCredentials) setCredentialsForm {
+	current := resources.TCAdminCredentials{BaseUrl: "https://qp.qonzer.com"}
+	passwordPlaceholder := ""
+	if creds != nil {
+		current, passwordPlaceholder = *creds, secretPlaceholder
+		if u, err := creds.Url(); err == nil {
+			current.BaseUrl = u.String()
+		}
+	}
+	configIds := ""
+	if current.GameId != "" {
+		configIds = strings.Join([]string{current.GameId, current.ModId, current.FileId}, "/")
+	}
+	return setCredentialsForm{
 		Components: []discordgo.MessageComponent{
 			discordgo.ActionsRow{
 				Components: []discordgo.MessageComponent{
 					discordgo.TextInput{
 						CustomID: "base_url",
-						Label:    "Base URL without protocol",
-						Value:    "qp.qonzer.com",
+						Label:    "Base URL",
+						Value:    current.BaseUrl,
 						Style:    discordgo.TextInputShort,
 						Required: true,
 					},
@@ -276,6 +579,7 @@ var (
 					discordgo.TextInput{
 						CustomID: "service_id",
 						Label:    "Service ID",
+						Value:    current.ServiceId,
 						Style:    discordgo.TextInputShort,
 						Required: true,
 					},
@@ -286,6 +590,7 @@ var (
 					discordgo.TextInput{
 						CustomID: "username",
 						Label:    "Username",
+						Value:    current.Username,
 						Style:    discordgo.TextInputShort,
 						Required: true,
 					},
@@ -294,10 +599,23 @@ var (
 			discordgo.ActionsRow{
 				Components: []discordgo.MessageComponent{
 					discordgo.TextInput{
-						CustomID: "password",
-						Label:    "Password",
-						Style:    discordgo.TextInputShort,
-						Required: true,
+						CustomID:    "password",
+						Label:       "Password",
+						Placeholder: passwordPlaceholder,
+						Style:       discordgo.TextInputShort,
+						Required:    creds == nil,
+					},
+				},
+			},
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:    "config_ids",
+						Label:       "Game, mod and file ID (game/mod/file)",
+						Placeholder: "Leave empty to detect them automatically",
+						Value:       configIds,
+						Style:       discordgo.TextInputShort,
+						Required:    false,
 					},
 				},
 			},
@@ -305,15 +623,26 @@ var (
 		Title:    "Set TCAdmin Credentials",
 		CustomID: customId(credentialsPrefix, "confirm-tcadmin"),
 	}
-)
+}
 
-func (c *CredentialsCommand) onSetCredentialsClick(s *discordgo.Session, i *discordgo.InteractionCreate, form setCredentialsForm, serverId string) {
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+func (c *CredentialsCommand) onSetCredentialsClick(s *discordgo.Session, i *discordgo.InteractionCreate, form func(*resources.Server) setCredentialsForm, serverId string) {
+	server, err := c.servers.Find(serverId)
+	if err != nil {
+		c.logger.Error("find-server", "error", err)
+		storageErrorResponse(s, i.Interaction, "There was an error fetching server details.", err)
+		return
+	}
+	if server == nil {
+		ErrorResponse(s, i.Interaction, "Could not find server with ID "+serverId)
+		return
+	}
+	f := form(server)
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseModal,
 		Data: &discordgo.InteractionResponseData{
-			Title:      form.Title,
-			Components: form.Components,
-			CustomID:   customId(form.CustomID, serverId),
+			Title:      f.Title,
+			Components: f.Components,
+			CustomID:   customId(f.CustomID, serverId),
 		},
 	})
 	if err != nil {
@@ -322,30 +651,97 @@ func (c *CredentialsCommand) onSetCredentialsClick(s *discordgo.Session, i *disc
 	}
 }
 
+func pterodactylForm(creds *resources.PterodactylCredentials) setCredentialsForm {
+	current := resources.PterodactylCredentials{NameVariable: "SERVER_NAME", PasswordVariable: "SERVER_PASSWORD"}
+	apiKeyPlaceholder := ""
+	if creds != nil {
+		current, apiKeyPlaceholder = *creds, secretPlaceholder
+	}
+	return setCredentialsForm{
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:    "panel_url",
+						Label:       "Panel URL",
+						Placeholder: "https://panel.example.com",
+						Value:       current.BaseUrl,
+						Style:       discordgo.TextInputShort,
+						Required:    true,
+					},
+				},
+			},
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID: "server_id",
+						Label:    "Server ID (as shown in the panel URL)",
+						Value:    current.ServerId,
+						Style:    discordgo.TextInputShort,
+						Required: true,
+					},
+				},
+			},
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID:    "api_key",
+						Label:       "Client API Key",
+						Placeholder: apiKeyPlaceholder,
+						Style:       discordgo.TextInputShort,
+						Required:    creds == nil,
+					},
+				},
+			},
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID: "name_variable",
+						Label:    "Startup variable of the server name",
+						Value:    current.NameVariable,
+						Style:    discordgo.TextInputShort,
+						Required: true,
+					},
+				},
+			},
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						CustomID: "password_variable",
+						Label:    "Startup variable of the server password",
+						Value:    current.PasswordVariable,
+						Style:    discordgo.TextInputShort,
+						Required: true,
+					},
+				},
+			},
+		},
+		Title:    "Set Pterodactyl Credentials",
+		CustomID: customId(credentialsPrefix, "confirm-pterodactyl"),
+	}
+}
+
 func (c *CredentialsCommand) OnModalSubmit(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	id := i.ModalSubmitData().CustomID
 	peek, _ := peekId(id)
 	if matchesId(id, customId(credentialsPrefix, "confirm-crcon")) {
 		c.onConfirmCRConCredentials(s, i, peek)
+	} else if matchesId(id, customId(credentialsPrefix, "confirm-rcon")) {
+		c.onConfirmRConCredentials(s, i, peek)
 	} else if matchesId(id, customId(credentialsPrefix, "confirm-tcadmin")) {
 		c.onConfirmTCAdminCredentials(s, i, peek)
+	} else if matchesId(id, customId(credentialsPrefix, "confirm-pterodactyl")) {
+		c.onConfirmPterodactylCredentials(s, i, peek)
 	}
 }
 
 func (c *CredentialsCommand) onConfirmCRConCredentials(s *discordgo.Session, i *discordgo.InteractionCreate, serverId string) {
 	var d setCRConFormData
 	if err := marshaller.Unmarshal(i.ModalSubmitData().Components, &d); err != nil {
-		c.logger.Error("parse-data", err)
+		c.logger.Error("parse-data", "error", err)
 		ErrorResponse(s, i.Interaction, "Unknown error: "+err.Error())
 		return
 	}
-	u, err := url.Parse(d.Url)
-	if err != nil {
-		c.logger.Error("parse-url", "error", err)
-		ErrorResponse(s, i.Interaction, "Could not verify permissions of the provided credentials. Error: "+err.Error())
-		return
-	}
-	u.RawPath = ""
 	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
@@ -355,7 +751,7 @@ func (c *CredentialsCommand) onConfirmCRConCredentials(s *discordgo.Session, i *
 	server, err := c.servers.Find(serverId)
 	if err != nil {
 		c.logger.Error("get-server", "error", err)
-		ErrorResponse(s, i.Interaction, "Could not find server with ID "+serverId+": "+err.Error())
+		storageErrorResponse(s, i.Interaction, "Could not find server with ID "+serverId+".", err)
 		return
 	}
 	if server == nil {
@@ -367,50 +763,109 @@ func (c *CredentialsCommand) onConfirmCRConCredentials(s *discordgo.Session, i *
 		BaseUrl: d.Url,
 		ApiKey:  d.ApiKey,
 	}
-	client := crconClient(creds)
-	if p, err := client.OwnPermissions(context.Background()); err != nil {
+	if creds.ApiKey == "" && server.CRConCredentials != nil && creds.SameTarget(*server.CRConCredentials) {
+		creds.ApiKey = server.CRConCredentials.ApiKey
+	}
+	if creds.ApiKey == "" {
+		ErrorResponse(s, i.Interaction, "Please provide an API key. The stored API key is only kept when the URL is unchanged.")
+		return
+	}
+	if n, err := strconv.Atoi(d.Instance); err == nil {
+		creds.ServerNumber = n
+	} else {
+		creds.ApiPrefix = d.Instance
+	}
+	if err := creds.Validate(); err != nil {
+		ErrorResponse(s, i.Interaction, "The provided credentials are not valid. Error: "+err.Error())
+		return
+	}
+	ctx, cancel := operationContext(i.Interaction)
+	defer cancel()
+	if creds.ServerNumber > 0 {
+		creds.InstanceUrl, err = crconapi.NewClient(http.Client{Timeout: backendTimeout}, creds.BaseUrl, creds.ApiKey).ResolveInstance(ctx, creds.ServerNumber)
+		if err != nil {
+			c.logger.Error("resolve-crcon-instance", "error", err)
+			ErrorResponse(s, i.Interaction, "Could not find the CRCon server with number "+d.Instance+". "+resilience.Describe(err))
+			return
+		}
+	}
+	p, err := newCRConClient(creds).OwnPermissions(ctx)
+	if err != nil {
 		c.logger.Error("request-permissions", "error", err)
-		ErrorResponse(s, i.Interaction, "Could not verify permissions of the provided credentials. Error: "+err.Error())
+		ErrorResponse(s, i.Interaction, "Could not verify permissions of the provided credentials. "+resilience.Describe(err))
 		return
-	} else if miss := missingElements(p.Permissions, requiredPermissions); len(miss) > 0 {
-		ErrorResponse(
-			s, i.Interaction,
-			fmt.Sprintf(
-				"The provided API key grants more or less permissions than the required ones. Please only provide the required permissions.\n\nMissing:\n```\n%s```\n\nRequired:\n```\n%s```",
-				strings.Join(miss, "\n"),
-				strings.Join(requiredPermissions, "\n"),
-			),
-		)
+	}
+	status := internal.CheckPermissions(p)
+	if len(status.Missing) > 0 {
+		ErrorResponse(s, i.Interaction, "The provided API key misses some of the required permissions.\n\n"+permissionReport(status))
+		return
+	} else if !status.LeastPrivilege() && c.config.Strictness() == internal.StrictnessReject {
+		ErrorResponse(s, i.Interaction, "The provided API key grants more permissions than the required ones. Please only provide the required permissions.\n\n"+permissionReport(status))
 		return
 	}
+	creds.PermissionStatus = &status
 
 	server.CRConCredentials = &creds
 	if err := c.servers.Save(*server); err != nil {
 		c.logger.Error("save-server", "error", err)
-		ErrorResponse(s, i.Interaction, "Couldn't save server data. Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "Couldn't save server data.", err)
 		return
 	}
+	message := "CRCon credentials set for " + crconInstance(creds) + ". Refresh the embed to see the new status."
+	if !status.LeastPrivilege() {
+		message += "\n\n**Warning:** The API key grants more permissions than required.\n\n" + permissionReport(status)
+	}
 	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-		Content: String("CRCon credentials set. Refresh the embed to see the new status."),
+		Content: &message,
 	})
 	if err != nil {
 		c.logger.Error("edit-original-message", "error", err)
 	}
 }
 
-func missingElements[T comparable](s []T, l []T) (res []T) {
-	for _, t := range l {
-		if !slices.Contains(s, t) {
-			res = append(res, t)
+// permissionReport lists the missing, excess and granted required permissions of an API key. Long lists are cut to
+// stay within the message size limit of Discord.
+func permissionReport(p resources.PermissionStatus) string {
+	var b strings.Builder
+	if p.Superuser {
+		b.WriteString("The API key belongs to a **superuser**, which grants every permission.\n\n")
+	}
+	for _, l := range []struct {
+		title string
+		perms []string
+	}{{"Missing", p.Missing}, {"Extra", p.Extra}, {"OK", p.Ok}} {
+		if len(l.perms) == 0 {
+			continue
+		}
+		perms := l.perms
+		if len(perms) > 20 {
+			perms = append(slices.Clone(perms[:20]), fmt.Sprintf("... and %d more", len(l.perms)-20))
 		}
+		b.WriteString(fmt.Sprintf("%s (%d):\n```\n%s\n```\n", l.title, len(l.perms), strings.Join(perms, "\n")))
 	}
-	return
+	return b.String()
+}
+
+// permissionSummary is a one-line representation of the last permission check of an API key.
+func permissionSummary(p *resources.PermissionStatus) string {
+	if p == nil {
+		return "Permissions not verified yet"
+	}
+	state := "least privilege"
+	if p.Superuser {
+		state = "superuser key"
+	} else if len(p.Missing) > 0 {
+		state = fmt.Sprintf("%d missing", len(p.Missing))
+	} else if len(p.Extra) > 0 {
+		state = fmt.Sprintf("%d extra", len(p.Extra))
+	}
+	return fmt.Sprintf("Permissions: %s (verified <t:%d:R>)", state, p.CheckedAt.Unix())
 }
 
 func (c *CredentialsCommand) onConfirmTCAdminCredentials(s *discordgo.Session, i *discordgo.InteractionCreate, serverId string) {
 	var d setTCAdminFormData
 	if err := marshaller.Unmarshal(i.ModalSubmitData().Components, &d); err != nil {
-		c.logger.Error("parse-data", err)
+		c.logger.Error("parse-data", "error", err)
 		ErrorResponse(s, i.Interaction, "Unknown error: "+err.Error())
 		return
 	}
@@ -423,7 +878,7 @@ func (c *CredentialsCommand) onConfirmTCAdminCredentials(s *discordgo.Session, i
 	server, err := c.servers.Find(serverId)
 	if err != nil {
 		c.logger.Error("get-server", "error", err)
-		ErrorResponse(s, i.Interaction, "Could not find server with ID "+serverId+": "+err.Error())
+		storageErrorResponse(s, i.Interaction, "Could not find server with ID "+serverId+".", err)
 		return
 	}
 	if server == nil {
@@ -432,26 +887,193 @@ func (c *CredentialsCommand) onConfirmTCAdminCredentials(s *discordgo.Session, i
 	}
 
 	creds := resources.TCAdminCredentials{
-		BaseUrl:   d.Url,
+		BaseUrl:   strings.TrimSuffix(d.Url, "/"),
 		ServiceId: d.ServiceId,
 		Username:  d.Username,
 		Password:  d.Password,
 	}
-	client := tcadminClient(creds)
+	if creds.Password == "" && server.TCAdminCredentials != nil && creds.SameTarget(*server.TCAdminCredentials) {
+		creds.Password = server.TCAdminCredentials.Password
+	}
+	if creds.Password == "" {
+		ErrorResponse(s, i.Interaction, "Please provide a password. The stored password is only kept when the URL and username are unchanged.")
+		return
+	}
+	if d.ConfigIds != "" {
+		creds.GameId, creds.ModId, creds.FileId, err = resources.ParseConfigIds(d.ConfigIds)
+		if err != nil {
+			ErrorResponse(s, i.Interaction, "The provided credentials are not valid. Error: "+err.Error())
+			return
+		}
+	}
+	if err := creds.Validate(); err != nil {
+		ErrorResponse(s, i.Interaction, "The provided credentials are not valid. Error: "+err.Error())
+		return
+	}
+	message := "TCAdmin credentials set. Refresh the embed to see the new status."
+	if creds.GameId == "" {
+		u, _ := creds.Url()
+		gameId, modId, fileId, err := hosting.DetectTCAdminConfig(http.Client{Timeout: backendTimeout}, u.String(), creds.ServiceId, creds.Username, creds.Password)
+		if err != nil {
+			c.logger.Info("detect-tcadmin-config", "error", err)
+			message += "\n\nThe game, mod and file IDs could not be detected, the ones of Hell Let Loose are used."
+		} else {
+			creds.GameId, creds.ModId, creds.FileId = gameId, modId, fileId
+			message += fmt.Sprintf("\n\nDetected game ID %s, mod ID %s and file ID %s.", gameId, modId, fileId)
+		}
+	}
+	client := tcadminClient(serverId, creds)
 	if _, err := client.ServerInfo(d.ServiceId); err != nil {
 		c.logger.Error("request-status", "error", err)
-		ErrorResponse(s, i.Interaction, "Could not verify permissions of the provided credentials. Error: "+err.Error())
+		ErrorResponse(s, i.Interaction, "Could not verify permissions of the provided credentials. "+resilience.Describe(err))
 		return
 	}
 
 	server.TCAdminCredentials = &creds
+	server.HostingProvider = resources.HostingTCAdmin
+	if err := c.servers.Save(*server); err != nil {
+		c.logger.Error("save-server", "error", err)
+		storageErrorResponse(s, i.Interaction, "Couldn't save server data.", err)
+		return
+	}
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &message,
+	})
+	if err != nil {
+		c.logger.Error("edit-original-message", "error", err)
+	}
+}
+
+func (c *CredentialsCommand) onConfirmRConCredentials(s *discordgo.Session, i *discordgo.InteractionCreate, serverId string) {
+	var d setRConFormData
+	if err := marshaller.Unmarshal(i.ModalSubmitData().Components, &d); err != nil {
+		c.logger.Error("parse-data", "error", err)
+		ErrorResponse(s, i.Interaction, "Unknown error: "+err.Error())
+		return
+	}
+	port, err := strconv.Atoi(strings.TrimSpace(d.Port))
+	if err != nil {
+		ErrorResponse(s, i.Interaction, "The port must be a number.")
+		return
+	}
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	server, err := c.servers.Find(serverId)
+	if err != nil {
+		c.logger.Error("get-server", "error", err)
+		storageErrorResponse(s, i.Interaction, "Could not find server with ID "+serverId+".", err)
+		return
+	}
+	if server == nil {
+		ErrorResponse(s, i.Interaction, "Could not find server with ID "+serverId)
+		return
+	}
+
+	creds := resources.RConCredentials{
+		Host:     strings.TrimSpace(d.Host),
+		Port:     port,
+		Password: d.Password,
+	}
+	if creds.Password == "" && server.RConCredentials != nil && creds.SameTarget(*server.RConCredentials) {
+		creds.Password = server.RConCredentials.Password
+	}
+	if creds.Password == "" {
+		ErrorResponse(s, i.Interaction, "Please provide a password. The stored password is only kept when the host and port are unchanged.")
+		return
+	}
+	if err := creds.Validate(); err != nil {
+		ErrorResponse(s, i.Interaction, "The provided credentials are not valid. Error: "+err.Error())
+		return
+	}
+	ctx, cancel := operationContext(i.Interaction)
+	defer cancel()
+	if _, err := newRConClient(creds).OwnPermissions(ctx); err != nil {
+		c.logger.Error("rcon-login", "error", err)
+		ErrorResponse(s, i.Interaction, "Could not verify the provided credentials. "+resilience.Describe(err))
+		return
+	}
+
+	server.RConCredentials = &creds
+	if err := c.servers.Save(*server); err != nil {
+		c.logger.Error("save-server", "error", err)
+		storageErrorResponse(s, i.Interaction, "Couldn't save server data.", err)
+		return
+	}
+	message := "RCON credentials set. Refresh the embed to see the new status."
+	if server.CRConCredentials != nil {
+		message += "\n\n**Note:** The server is managed through CRCon as long as CRCon credentials are set."
+	}
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &message,
+	})
+	if err != nil {
+		c.logger.Error("edit-original-message", "error", err)
+	}
+}
+
+func (c *CredentialsCommand) onConfirmPterodactylCredentials(s *discordgo.Session, i *discordgo.InteractionCreate, serverId string) {
+	var d setPterodactylFormData
+	if err := marshaller.Unmarshal(i.ModalSubmitData().Components, &d); err != nil {
+		c.logger.Error("parse-data", "error", err)
+		ErrorResponse(s, i.Interaction, "Unknown error: "+err.Error())
+		return
+	}
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	server, err := c.servers.Find(serverId)
+	if err != nil {
+		c.logger.Error("get-server", "error", err)
+		storageErrorResponse(s, i.Interaction, "Could not find server with ID "+serverId+".", err)
+		return
+	}
+	if server == nil {
+		ErrorResponse(s, i.Interaction, "Could not find server with ID "+serverId)
+		return
+	}
+
+	creds := resources.PterodactylCredentials{
+		BaseUrl:          strings.TrimSuffix(d.Url, "/"),
+		ServerId:         d.ServerId,
+		ApiKey:           d.ApiKey,
+		NameVariable:     d.NameVariable,
+		PasswordVariable: d.PasswordVariable,
+	}
+	if creds.ApiKey == "" && server.PterodactylCredentials != nil && creds.SameTarget(*server.PterodactylCredentials) {
+		creds.ApiKey = server.PterodactylCredentials.ApiKey
+	}
+	if creds.ApiKey == "" {
+		ErrorResponse(s, i.Interaction, "Please provide an API key. The stored API key is only kept when the panel URL and server ID are unchanged.")
+		return
+	}
+	if err := creds.Validate(); err != nil {
+		ErrorResponse(s, i.Interaction, "The provided credentials are not valid. Error: "+err.Error())
+		return
+	}
+	ctx, cancel := operationContext(i.Interaction)
+	defer cancel()
+	if _, err := hosting.NewPterodactyl(http.Client{Timeout: backendTimeout}, creds).ServerInfo(ctx); err != nil {
+		c.logger.Error("request-server-info", "error", err)
+		ErrorResponse(s, i.Interaction, "Could not verify the provided credentials. "+resilience.Describe(err))
+		return
+	}
+
+	server.PterodactylCredentials = &creds
+	server.HostingProvider = resources.HostingPterodactyl
 	if err := c.servers.Save(*server); err != nil {
 		c.logger.Error("save-server", "error", err)
-		ErrorResponse(s, i.Interaction, "Couldn't save server data. Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "Couldn't save server data.", err)
 		return
 	}
 	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-		Content: String("TCAdmin credentials set. Refresh the embed to see the new status."),
+		Content: String("Pterodactyl credentials set, the server is now managed through Pterodactyl. Refresh the embed to see the new status."),
 	})
 	if err != nil {
 		c.logger.Error("edit-original-message", "error", err)
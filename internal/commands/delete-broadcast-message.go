@@ -22,14 +22,16 @@ type autocompleteBroadcastMessageData struct {
 type DeleteBroadcastMessageCommand struct {
 	logger    *slog.Logger
 	config    *internal.Config
-	templates internal.Storage[resources.Template]
+	templates internal.Repository[resources.Template]
+	servers   internal.Repository[resources.Server]
 }
 
-func NewDeleteBroadcastMessageCommand(l *slog.Logger, c *internal.Config, m internal.Storage[resources.Template]) *DeleteBroadcastMessageCommand {
+func NewDeleteBroadcastMessageCommand(l *slog.Logger, c *internal.Config, m internal.Repository[resources.Template], servers internal.Repository[resources.Server]) *DeleteBroadcastMessageCommand {
 	return &DeleteBroadcastMessageCommand{
 		logger:    l,
 		config:    c,
 		templates: m,
+		servers:   servers,
 	}
 }
 
@@ -82,25 +84,13 @@ func (c *DeleteBroadcastMessageCommand) OnAutocomplete(s *discordgo.Session, i *
 }
 
 func (c *DeleteBroadcastMessageCommand) autocompleteTemplates(s *discordgo.Session, i *discordgo.InteractionCreate) (choices []*discordgo.ApplicationCommandOptionChoice) {
-	l, err := c.templates.List()
+	l, err := c.templates.Search(focusedValue(i))
 	if err != nil {
 		c.logger.Error("list-templates", "error", err)
-		ErrorResponse(s, i.Interaction, "Could not list templates. Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "Could not list templates.", err)
 		return
 	}
-	for _, tid := range l {
-		tpl, err := c.templates.Find(tid)
-		if err != nil {
-			c.logger.Error("find-templates", "error", err)
-			ErrorResponse(s, i.Interaction, "Could not find template with ID "+tid+". Error: "+err.Error())
-			continue
-		}
-		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
-			Name:  tpl.Name,
-			Value: tpl.TemplateId,
-		})
-	}
-	return
+	return autocompleteChoices(l)
 }
 
 func (c *DeleteBroadcastMessageCommand) autocompleteBroadcastMessages(s *discordgo.Session, i *discordgo.InteractionCreate) (choices []*discordgo.ApplicationCommandOptionChoice) {
@@ -113,7 +103,7 @@ func (c *DeleteBroadcastMessageCommand) autocompleteBroadcastMessages(s *discord
 	tpl, err := c.templates.Find(d.TemplateId)
 	if err != nil {
 		c.logger.Error("find-template", "error", err)
-		ErrorResponse(s, i.Interaction, "There was an error fetching template details. Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "There was an error fetching template details.", err)
 		return
 	}
 	if tpl == nil {
@@ -145,7 +135,7 @@ func (c *DeleteBroadcastMessageCommand) OnCommand(s *discordgo.Session, i *disco
 	tpl, err := c.templates.Find(d.TemplateId)
 	if err != nil {
 		c.logger.Error("find-template", "error", err)
-		ErrorResponse(s, i.Interaction, "There was an error fetching template details. Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "There was an error fetching template details.", err)
 		return
 	}
 	if tpl == nil {
@@ -159,14 +149,23 @@ func (c *DeleteBroadcastMessageCommand) OnCommand(s *discordgo.Session, i *disco
 		}
 	}
 	tpl.BroadcastMessage = newBm
+	tpl.Version++
 	err = c.templates.Save(*tpl)
 	if err != nil {
 		c.logger.Error("save-tpl", "error", err)
-		ErrorResponse(s, i.Interaction, "There was an error saving the template. Please try again. Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "There was an error saving the template. Please try again.", err)
 		return
 	}
+	message := "The message was deleted from the template."
+	users, err := templateUsers(c.templates, c.servers, tpl.TemplateId)
+	if err != nil {
+		c.logger.Error("list-template-users", "error", err)
+	}
+	if w := usageWarning(users); w != "" {
+		message += "\n\n" + w
+	}
 	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-		Content: String("The message was deleted from the template."),
+		Content: &message,
 	})
 	if err != nil {
 		c.logger.Error("edit-response", "error", err)
@@ -0,0 +1,421 @@
+package commands
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"github.com/floriansw/go-crcon"
+	"github.com/floriansw/go-discordgo-utils/marshaller"
+	. "github.com/floriansw/go-discordgo-utils/util"
+	"github.com/floriansw/hll-discord-server-watcher/internal"
+	"github.com/floriansw/hll-discord-server-watcher/internal/hosting"
+	"github.com/floriansw/hll-discord-server-watcher/resources"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const diagnoseStepTimeout = 10 * time.Second
+
+type diagnoseData struct {
+	ServerId string `discordgo:"server"`
+}
+
+type DiagnoseCommand struct {
+	logger  *slog.Logger
+	config  *internal.Config
+	servers internal.Repository[resources.Server]
+}
+
+func NewDiagnoseCommand(l *slog.Logger, c *internal.Config, m internal.Repository[resources.Server]) *DiagnoseCommand {
+	return &DiagnoseCommand{
+		logger:  l,
+		config:  c,
+		servers: m,
+	}
+}
+
+func (c *DiagnoseCommand) Definition(cmd string) *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        cmd,
+		Description: "Checks step by step if the bot can connect to the CRCon and hosting provider of a server",
+		Options: []*discordgo.ApplicationCommandOption{{
+			Name:         "server",
+			Description:  "The server ID to diagnose",
+			Type:         discordgo.ApplicationCommandOptionString,
+			Required:     true,
+			Autocomplete: true,
+		}},
+	}
+}
+
+func (c *DiagnoseCommand) OnAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	l, err := c.servers.Search(focusedValue(i))
+	if err != nil {
+		c.logger.Error("list-servers", "error", err)
+		storageErrorResponse(s, i.Interaction, "Could not list servers.", err)
+		return
+	}
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: autocompleteChoices(l),
+		},
+	})
+	if err != nil {
+		c.logger.Error("response", "error", err)
+		ErrorResponse(s, i.Interaction, "Could not send response. Error: "+err.Error())
+		return
+	}
+}
+
+func (c *DiagnoseCommand) OnCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	var d diagnoseData
+	if err := marshaller.Unmarshal(i.Interaction.ApplicationCommandData().Options, &d); err != nil {
+		c.logger.Error("load-diagnose-data", "error", err)
+		ErrorResponse(s, i.Interaction, "Could not load data from interaction. Error: "+err.Error())
+		return
+	}
+	server, err := c.servers.Find(d.ServerId)
+	if err != nil {
+		c.logger.Error("find-server", "error", err)
+		storageErrorResponse(s, i.Interaction, "There was an error fetching server details.", err)
+		return
+	}
+	if server == nil {
+		ErrorResponse(s, i.Interaction, "Could not find server with ID "+d.ServerId)
+		return
+	}
+
+	embeds := []*discordgo.MessageEmbed{diagnoseCRCon(server.CRConCredentials).embed("CRCon")}
+	if server.RConCredentials != nil {
+		embeds = append(embeds, diagnoseRCon(server.RConCredentials).embed("RCON"))
+	}
+	if server.Hosting() == resources.HostingPterodactyl {
+		embeds = append(embeds, diagnosePterodactyl(server.PterodactylCredentials).embed("Pterodactyl"))
+	} else {
+		embeds = append(embeds, diagnoseTCAdmin(server.TCAdminCredentials).embed("TCAdmin"))
+	}
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: String(fmt.Sprintf("Diagnostics for **%s**", server.Name)),
+		Embeds:  &embeds,
+	})
+	if err != nil {
+		c.logger.Error("edit-response", "error", err)
+	}
+}
+
+type diagnosticStep struct {
+	name     string
+	duration time.Duration
+	detail   string
+	err      error
+	hint     string
+	skipped  bool
+}
+
+// diagnosis runs steps in order, a step is skipped once a previous one failed as it would fail for the same reason.
+type diagnosis struct {
+	steps  []diagnosticStep
+	failed bool
+}
+
+func (d *diagnosis) run(name, hint string, f func(ctx context.Context) (string, error)) {
+	if d.failed {
+		d.steps = append(d.steps, diagnosticStep{name: name, skipped: true})
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), diagnoseStepTimeout)
+	defer cancel()
+	start := time.Now()
+	detail, err := f(ctx)
+	step := diagnosticStep{name: name, duration: time.Since(start), detail: detail, err: err}
+	if err != nil {
+		step.hint = hint
+		d.failed = true
+	}
+	d.steps = append(d.steps, step)
+}
+
+func (d *diagnosis) embed(title string) *discordgo.MessageEmbed {
+	e := &discordgo.MessageEmbed{Title: title, Color: ColorGreen}
+	if len(d.steps) == 0 {
+		e.Description = "No credentials set."
+		e.Color = ColorDarkGrey
+		return e
+	}
+	if d.failed {
+		e.Color = ColorDarkRed
+	}
+	for idx, step := range d.steps {
+		f := &discordgo.MessageEmbedField{}
+		switch {
+		case step.skipped:
+			f.Name = fmt.Sprintf("%d. ⏭️ %s", idx+1, step.name)
+			f.Value = "Skipped because a previous step failed."
+		case step.err != nil:
+			f.Name = fmt.Sprintf("%d. ❌ %s (%s)", idx+1, step.name, step.duration.Round(time.Millisecond))
+			f.Value = fmt.Sprintf("%s\n```\n%s\n```", step.hint, step.err.Error())
+		default:
+			f.Name = fmt.Sprintf("%d. ✅ %s (%s)", idx+1, step.name, step.duration.Round(time.Millisecond))
+			f.Value = valOrNotSet(step.detail)
+		}
+		e.Fields = append(e.Fields, f)
+	}
+	return e
+}
+
+// connectivity adds the steps which are needed to reach an HTTP(S) endpoint.
+func (d *diagnosis) connectivity(raw string, u **url.URL) {
+	d.run("URL parsing", "The URL is not valid. It should look like https://example.com.", func(ctx context.Context) (string, error) {
+		p, err := url.Parse(raw)
+		if err != nil {
+			return "", err
+		}
+		if p.Scheme != "http" && p.Scheme != "https" {
+			return "", fmt.Errorf("unsupported protocol %q, expected http or https", p.Scheme)
+		}
+		if p.Hostname() == "" {
+			return "", errors.New("the URL does not contain a host name")
+		}
+		*u = p
+		return p.String(), nil
+	})
+	d.run("DNS resolution", "The host name could not be found. Check the URL for typos.", func(ctx context.Context) (string, error) {
+		addrs, err := net.DefaultResolver.LookupHost(ctx, (*u).Hostname())
+		if err != nil {
+			return "", err
+		}
+		return strings.Join(addrs, ", "), nil
+	})
+	d.run("TCP/TLS handshake", "The server could not be reached. It might be offline, or a firewall blocks the connection.", func(ctx context.Context) (string, error) {
+		addr := net.JoinHostPort((*u).Hostname(), port(*u))
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+		if (*u).Scheme != "https" {
+			return "Connected to " + addr, nil
+		}
+		tc := tls.Client(conn, &tls.Config{ServerName: (*u).Hostname()})
+		if err := tc.HandshakeContext(ctx); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Connected to %s using %s", addr, tls.VersionName(tc.ConnectionState().Version)), nil
+	})
+}
+
+func port(u *url.URL) string {
+	if p := u.Port(); p != "" {
+		return p
+	}
+	if u.Scheme == "https" {
+		return "443"
+	}
+	return "80"
+}
+
+func diagnoseCRCon(creds *resources.CRConCredentials) *diagnosis {
+	d := &diagnosis{}
+	if creds == nil {
+		return d
+	}
+	var u *url.URL
+	d.connectivity(creds.ApiUrl(), &u)
+	d.run("HTTP status", "The server answered with an error. Check that the URL points to your CRCon installation.", func(ctx context.Context) (string, error) {
+		r, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return "", err
+		}
+		res, err := http.DefaultClient.Do(r)
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+		if res.StatusCode >= http.StatusInternalServerError {
+			return "", fmt.Errorf("unexpected status: %s", res.Status)
+		}
+		return res.Status, nil
+	})
+	d.run("CRCon authentication and permissions", "The API key was rejected or lacks permissions. Create a new API key in CRCon and set the credentials again.", func(ctx context.Context) (string, error) {
+		p, err := newCRConClient(*creds).OwnPermissions(ctx)
+		if errors.Is(err, crcon.ErrForbidden) {
+			return "", errors.New("the API key is not allowed to read its own permissions")
+		} else if err != nil {
+			return "", err
+		}
+		status := internal.CheckPermissions(p)
+		if len(status.Missing) > 0 {
+			return "", fmt.Errorf("missing permissions: %s", strings.Join(status.Missing, ", "))
+		}
+		return fmt.Sprintf("Authenticated as %s, %d of %d required permissions granted, %d extra", p.Username, len(status.Ok), len(internal.RequiredPermissions), len(status.Extra)), nil
+	})
+	return d
+}
+
+func diagnoseTCAdmin(creds *resources.TCAdminCredentials) *diagnosis {
+	d := &diagnosis{}
+	if creds == nil {
+		return d
+	}
+	var u *url.URL
+	base := creds.BaseUrl
+	if cu, err := creds.Url(); err == nil {
+		base = cu.String()
+	}
+	d.connectivity(base, &u)
+	jar, _ := cookiejar.New(nil)
+	hc := &http.Client{
+		Jar: jar,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	// u is only set when the URL could be parsed, the steps using it are skipped otherwise
+	loginUrl := func() string {
+		return u.JoinPath("/Aspx/Interface/Base/Login.aspx").String()
+	}
+	d.run("HTTP status", "The server answered with an error. Check that the base URL points to your TCAdmin panel.", func(ctx context.Context) (string, error) {
+		r, err := http.NewRequestWithContext(ctx, http.MethodGet, loginUrl(), nil)
+		if err != nil {
+			return "", err
+		}
+		res, err := hc.Do(r)
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+		if res.StatusCode >= http.StatusBadRequest {
+			return "", fmt.Errorf("unexpected status: %s", res.Status)
+		}
+		return res.Status, nil
+	})
+	d.run("TCAdmin login", "The username or password is wrong. Set the TCAdmin credentials again.", func(ctx context.Context) (string, error) {
+		r, err := http.NewRequestWithContext(ctx, http.MethodGet, loginUrl(), nil)
+		if err != nil {
+			return "", err
+		}
+		r.SetBasicAuth(creds.Username, creds.Password)
+		res, err := hc.Do(r)
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusFound {
+			return "", fmt.Errorf("expected a redirect after login, got status %s", res.Status)
+		}
+		return "Logged in as " + creds.Username, nil
+	})
+	d.run("Config page parsing", "The config page of the server could not be read. Check the service ID, the game, mod and file IDs and that the account can access the server's config files.", func(ctx context.Context) (string, error) {
+		gameId, modId, fileId := tcadminConfigIds(*creds)
+		q := url.Values{
+			"gameid":    {gameId},
+			"modid":     {modId},
+			"fileid":    {fileId},
+			"serviceid": {creds.ServiceId},
+		}
+		cu := u.JoinPath("/Aspx/Interface/GameHosting/MvcConfigEditor.aspx")
+		cu.RawQuery = q.Encode()
+		r, err := http.NewRequestWithContext(ctx, http.MethodGet, cu.String(), nil)
+		if err != nil {
+			return "", err
+		}
+		res, err := hc.Do(r)
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("unexpected status %s with Location %s", res.Status, res.Header.Get("Location"))
+		}
+		b, err := io.ReadAll(res.Body)
+		if err != nil {
+			return "", err
+		}
+		var missing []string
+		for _, field := range []string{"__VSTATE", "__EVENTVALIDATION"} {
+			if !strings.Contains(string(b), `name="`+field+`"`) {
+				missing = append(missing, field)
+			}
+		}
+		if len(missing) > 0 {
+			return "", fmt.Errorf("the page misses the form fields %s", strings.Join(missing, ", "))
+		}
+		return "Found __VSTATE and __EVENTVALIDATION", nil
+	})
+	return d
+}
+
+func diagnoseRCon(creds *resources.RConCredentials) *diagnosis {
+	d := &diagnosis{}
+	if creds == nil {
+		return d
+	}
+	d.run("DNS resolution", "The host name could not be found. Check the host for typos.", func(ctx context.Context) (string, error) {
+		addrs, err := net.DefaultResolver.LookupHost(ctx, creds.Host)
+		if err != nil {
+			return "", err
+		}
+		return strings.Join(addrs, ", "), nil
+	})
+	d.run("TCP connection", "The RCON port could not be reached. Check the port, the server might be offline, or a firewall blocks the connection.", func(ctx context.Context) (string, error) {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", creds.Address())
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+		return "Connected to " + creds.Address(), nil
+	})
+	c := newRConClient(*creds)
+	d.run("RCON login", "The server rejected the password. Check the RCON password in the server settings of your host.", func(ctx context.Context) (string, error) {
+		if _, err := c.OwnPermissions(ctx); err != nil {
+			return "", err
+		}
+		return "Logged in", nil
+	})
+	d.run("Player list", "The server did not answer a command. The RCON interface might be overloaded.", func(ctx context.Context) (string, error) {
+		ids, err := c.PlayerIds(ctx)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d players online", len(ids)), nil
+	})
+	return d
+}
+
+func diagnosePterodactyl(creds *resources.PterodactylCredentials) *diagnosis {
+	d := &diagnosis{}
+	if creds == nil {
+		return d
+	}
+	var u *url.URL
+	d.connectivity(creds.BaseUrl, &u)
+	p := hosting.NewPterodactyl(http.Client{Timeout: diagnoseStepTimeout}, *creds)
+	d.run("Pterodactyl API", "The panel rejected the request. Check the API key, which must be a client API key of an account with access to the server, and the server ID.", func(ctx context.Context) (string, error) {
+		if _, err := p.ServerInfo(ctx); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Found the startup variables %s and %s", creds.NameVariable, creds.PasswordVariable), nil
+	})
+	d.run("Server status", "The status of the server could not be read. The account might lack the permission to view the server's resources.", func(ctx context.Context) (string, error) {
+		st, err := p.Status(ctx)
+		return string(st), err
+	})
+	return d
+}
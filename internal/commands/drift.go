@@ -0,0 +1,332 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	. "github.com/floriansw/go-discordgo-utils/util"
+	"github.com/floriansw/hll-discord-server-watcher/internal"
+	"github.com/floriansw/hll-discord-server-watcher/internal/resilience"
+	"github.com/floriansw/hll-discord-server-watcher/resources"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+const driftPrefix = "drift"
+
+// DriftCheck periodically compares the settings of servers with the template which was applied to them last and
+// reports settings which were changed outside of the bot, e.g. directly in CRCon or the panel of the host.
+type DriftCheck struct {
+	logger    *slog.Logger
+	config    *internal.Config
+	servers   internal.Repository[resources.Server]
+	templates internal.Repository[resources.Template]
+}
+
+func NewDriftCheck(l *slog.Logger, c *internal.Config, s internal.Repository[resources.Server], t internal.Repository[resources.Template]) *DriftCheck {
+	return &DriftCheck{
+		logger:    l,
+		config:    c,
+		servers:   s,
+		templates: t,
+	}
+}
+
+// Schedule runs the drift check every configured interval until stop is closed. It returns immediately when the
+// drift check is disabled.
+func (d *DriftCheck) Schedule(s *discordgo.Session, stop <-chan struct{}) {
+	if d.config.DriftCheck == nil || d.config.DriftCheck.IntervalMinutes <= 0 {
+		return
+	}
+	t := time.NewTicker(time.Duration(d.config.DriftCheck.IntervalMinutes) * time.Minute)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+			d.checkAll(s)
+		}
+	}
+}
+
+func (d *DriftCheck) checkAll(s *discordgo.Session) {
+	summaries, err := d.servers.ListSummaries()
+	if err != nil {
+		d.logger.Error("drift-check-list-servers", "error", err)
+		return
+	}
+	for _, summary := range summaries {
+		if summary.TemplateId == "" || !summary.Manageable {
+			continue
+		}
+		id := summary.Id
+		server, err := d.servers.Find(id)
+		if err != nil || server == nil {
+			d.logger.Error("drift-check-find-server", "server", id, "error", err)
+			continue
+		}
+		if server.AppliedTemplate == nil || !server.RConConfigured() {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), operationTimeout)
+		diffs, err := drift(ctx, *server)
+		cancel()
+		if err != nil {
+			d.logger.Error("drift-check-read-settings", "server", id, "error", err)
+			continue
+		}
+		if slices.Equal(diffs, server.AppliedTemplate.ReportedDrift) {
+			continue
+		}
+		if err := d.saveReported(*server, diffs); err != nil {
+			d.logger.Error("drift-check-save-server", "server", id, "error", err)
+		}
+		if len(diffs) == 0 {
+			continue
+		}
+		d.logger.Info("drift-check-alert", "server", id, "differences", len(diffs))
+		err = sendAlert(s, d.config, d.report(*server, diffs), []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Re-apply template",
+					Style:    discordgo.PrimaryButton,
+					CustomID: customId(driftPrefix, "reapply", server.ServerId),
+				}, discordgo.Button{
+					Label:    "Adopt live values into template",
+					Style:    discordgo.SecondaryButton,
+					CustomID: customId(driftPrefix, "adopt", server.ServerId),
+				},
+			}},
+		})
+		if err != nil {
+			d.logger.Error("drift-check-send-alert", "server", id, "error", err)
+		}
+	}
+}
+
+func (d *DriftCheck) report(server resources.Server, diffs []resources.Difference) []*discordgo.MessageEmbed {
+	templateName := server.AppliedTemplate.Values.Name
+	if t, err := d.templates.Find(server.AppliedTemplate.TemplateId); err == nil && t != nil {
+		templateName = t.Name
+	}
+	var fields []*discordgo.MessageEmbedField
+	for _, diff := range diffs {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  diff.Setting,
+			Value: fmt.Sprintf("Expected: %s\nActual: %s", driftValue(diff.Expected), driftValue(diff.Actual)),
+		})
+	}
+	return []*discordgo.MessageEmbed{{
+		Title: "Settings of " + server.Name + " changed",
+		Description: fmt.Sprintf("The following settings differ from the template **%s**, which was applied <t:%d:R>. They were changed outside of this bot.",
+			templateName, server.AppliedTemplate.AppliedAt.Unix()),
+		Color:  ColorDarkRed,
+		Fields: fields,
+	}}
+}
+
+// saveReported stores the reported differences on the latest version of the server, unless a template was applied
+// in the meantime.
+func (d *DriftCheck) saveReported(checked resources.Server, diffs []resources.Difference) error {
+	server, err := d.servers.Find(checked.ServerId)
+	if err != nil || server == nil || server.AppliedTemplate == nil {
+		return err
+	}
+	if !server.AppliedTemplate.AppliedAt.Equal(checked.AppliedTemplate.AppliedAt) {
+		return nil
+	}
+	server.AppliedTemplate.ReportedDrift = diffs
+	return d.servers.Save(*server)
+}
+
+func (d *DriftCheck) OnMessageComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	cid := i.Interaction.MessageComponentData().CustomID
+	if matchesId(cid, customId(driftPrefix, "reapply")) {
+		peek, _ := peekId(cid)
+		d.onReapply(s, i, peek)
+	} else if matchesId(cid, customId(driftPrefix, "adopt")) {
+		peek, _ := peekId(cid)
+		d.onAdopt(s, i, peek)
+	}
+}
+
+func (d *DriftCheck) CanHandle(customId string) bool {
+	return matchesId(customId, driftPrefix)
+}
+
+// findApplied defers the response to the interaction and returns the server and the template applied to it last.
+func (d *DriftCheck) findApplied(s *discordgo.Session, i *discordgo.InteractionCreate, sid string) (*resources.Server, *resources.Template, bool) {
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	server, err := d.servers.Find(sid)
+	if err != nil {
+		d.logger.Error("find-server", "error", err)
+		storageErrorResponse(s, i.Interaction, "Error trying to find server with ID "+sid+".", err)
+		return nil, nil, false
+	}
+	if server == nil || !server.RConConfigured() {
+		ErrorResponse(s, i.Interaction, "Could not find server with ID "+sid+".")
+		return nil, nil, false
+	}
+	if server.AppliedTemplate == nil {
+		ErrorResponse(s, i.Interaction, "No template was applied to this server yet.")
+		return nil, nil, false
+	}
+	template, err := d.templates.Find(server.AppliedTemplate.TemplateId)
+	if err != nil {
+		d.logger.Error("find-template", "error", err)
+		storageErrorResponse(s, i.Interaction, "Error trying to find template with ID "+server.AppliedTemplate.TemplateId+".", err)
+		return nil, nil, false
+	}
+	if template == nil {
+		ErrorResponse(s, i.Interaction, "The template applied to this server was deleted.")
+		return nil, nil, false
+	}
+	return server, template, true
+}
+
+func (d *DriftCheck) onReapply(s *discordgo.Session, i *discordgo.InteractionCreate, sid string) {
+	server, template, ok := d.findApplied(s, i, sid)
+	if !ok {
+		return
+	}
+	effective, _, err := serverTemplate(d.templates, *server, *template)
+	if err != nil {
+		ErrorResponse(s, i.Interaction, "The settings of the template can not be resolved. Error: "+err.Error())
+		return
+	}
+	ctx, cancel := operationContext(i.Interaction)
+	defer cancel()
+	applied, unapplied, errs := applyTemplate(ctx, crconClient(*server), effective)
+	applied.ServerNameTemplate = server.AppliedTemplate.Values.ServerNameTemplate
+	server.AppliedTemplate = &resources.AppliedTemplate{
+		TemplateId: template.TemplateId,
+		Version:    template.Version,
+		AppliedAt:  time.Now(),
+		Values:     applied,
+		Unapplied:  unapplied,
+	}
+	if err := d.servers.Save(*server); err != nil {
+		d.logger.Error("save-server", "error", err)
+		storageErrorResponse(s, i.Interaction, "Error saving server.", err)
+		return
+	}
+
+	message := fmt.Sprintf("The template **%s** was applied to **%s** again.", template.Name, server.Name)
+	if len(errs) != 0 {
+		message = "Some settings could not be updated. Any not mentioned setting was made successfully. Errors:\n\n"
+		for _, e := range errs {
+			message += "* " + e.Error() + "\n  " + resilience.Explain(e) + "\n"
+		}
+	}
+	if applied.ServerNameTemplate != "" {
+		message += "\n\nThe server name is not changed, as that requires a restart. Use the server embed to change it."
+	}
+	d.respond(s, i, message)
+}
+
+func (d *DriftCheck) onAdopt(s *discordgo.Session, i *discordgo.InteractionCreate, sid string) {
+	server, template, ok := d.findApplied(s, i, sid)
+	if !ok {
+		return
+	}
+	ctx, cancel := operationContext(i.Interaction)
+	defer cancel()
+	live, unread, err := liveSettings(ctx, *server)
+	if err != nil {
+		d.logger.Error("read-settings", "server", sid, "error", err)
+		ErrorResponse(s, i.Interaction, "Could not read the settings of the server. "+resilience.Describe(err))
+		return
+	}
+	var names []string
+	for _, diff := range server.AppliedTemplate.Drift(live, slices.Collect(maps.Keys(unread))...) {
+		names = append(names, diff.Setting)
+	}
+	if len(names) == 0 {
+		d.respond(s, i, "The settings of the server match the template, there is nothing to adopt.")
+		return
+	}
+	// settings the server overrides are adopted into the overrides instead of the template
+	var own, overridden []string
+	for _, name := range names {
+		if server.Overrides != nil && slices.Contains(server.Overrides.Overrides(), name) {
+			overridden = append(overridden, name)
+		} else {
+			own = append(own, name)
+		}
+	}
+	if len(own) != 0 {
+		adopted := template.Adopt(live, own)
+		adopted.Version++
+		if err := adopted.Validate(); err != nil {
+			ErrorResponse(s, i.Interaction, "The live values can not be stored in the template. Error: "+err.Error())
+			return
+		}
+		if err := d.templates.Save(adopted); err != nil {
+			d.logger.Error("save-template", "error", err)
+			storageErrorResponse(s, i.Interaction, "There was an error saving the template.", err)
+			return
+		}
+		if server.AppliedTemplate.Version == template.Version {
+			server.AppliedTemplate.Version = adopted.Version
+		}
+	}
+	if len(overridden) != 0 {
+		o := server.Overrides.Adopt(live, overridden)
+		server.Overrides = &o
+	}
+	server.AppliedTemplate.Values = server.AppliedTemplate.Values.Adopt(live, names)
+	server.AppliedTemplate.ReportedDrift = nil
+	if err := d.servers.Save(*server); err != nil {
+		d.logger.Error("save-server", "error", err)
+		storageErrorResponse(s, i.Interaction, "Error saving server.", err)
+		return
+	}
+	message := fmt.Sprintf("The live values of %s were adopted into the template **%s**.", strings.Join(own, ", "), template.Name)
+	if len(own) == 0 {
+		message = ""
+	}
+	if len(overridden) != 0 {
+		message += fmt.Sprintf("\nThe live values of %s were adopted into the overrides of **%s**.", strings.Join(overridden, ", "), server.Name)
+	}
+	d.respond(s, i, strings.TrimSpace(message))
+}
+
+func (d *DriftCheck) respond(s *discordgo.Session, i *discordgo.InteractionCreate, message string) {
+	_, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &message,
+	})
+	if err != nil {
+		d.logger.Error("edit-response", "error", err)
+	}
+}
+
+// drift compares the live settings of the server with the values of the template applied to it last. Settings which
+// can not be read are not compared.
+func drift(ctx context.Context, s resources.Server) ([]resources.Difference, error) {
+	live, unread, err := liveSettings(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	return s.AppliedTemplate.Drift(live, slices.Collect(maps.Keys(unread))...), nil
+}
+
+// driftValue formats a value for a field of the drift report, two values need to fit in the 1024 characters of a
+// field.
+func driftValue(v string) string {
+	if v == "" {
+		return "*empty*"
+	}
+	if r := []rune(v); len(r) > 490 {
+		return string(r[:490]) + "…"
+	}
+	return v
+}
@@ -8,25 +8,29 @@ import (
 	"github.com/floriansw/go-discordgo-utils/marshaller"
 	. "github.com/floriansw/go-discordgo-utils/util"
 	"github.com/floriansw/hll-discord-server-watcher/internal"
+	"github.com/floriansw/hll-discord-server-watcher/internal/resilience"
 	"github.com/floriansw/hll-discord-server-watcher/resources"
 	"log/slog"
+	"time"
 )
 
 const embedPrefix = "embed"
 
 type setNamePasswordData struct {
-	Name     string `discordgo:"name"`
-	Password string `discordgo:"password"`
+	Name          string `discordgo:"name"`
+	Password      string `discordgo:"password"`
+	NameVariables string `discordgo:"name-variables"`
 }
 
 type EmbedCommand struct {
 	logger    *slog.Logger
 	config    *internal.Config
-	servers   internal.Storage[resources.Server]
-	templates internal.Storage[resources.Template]
+	servers   internal.Repository[resources.Server]
+	templates internal.Repository[resources.Template]
+	maps      mapCatalog
 }
 
-func NewEmbedCommand(l *slog.Logger, c *internal.Config, s internal.Storage[resources.Server], t internal.Storage[resources.Template]) *EmbedCommand {
+func NewEmbedCommand(l *slog.Logger, c *internal.Config, s internal.Repository[resources.Server], t internal.Repository[resources.Template]) *EmbedCommand {
 	return &EmbedCommand{
 		logger:    l,
 		config:    c,
@@ -51,6 +55,74 @@ func (c *EmbedCommand) OnMessageComponent(s *discordgo.Session, i *discordgo.Int
 	} else if matchesId(cid, customId(embedPrefix, "save-restart")) {
 		peek, _ := peekId(cid)
 		c.onSaveRestart(s, i, peek)
+	} else if matchesId(cid, customId(embedPrefix, "reveal-password")) {
+		peek, _ := peekId(cid)
+		c.onRevealPassword(s, i, peek)
+	} else if matchesId(cid, customId(embedPrefix, "override")) {
+		peek, _ := peekId(cid)
+		c.onOverrideClick(s, i, peek)
+	} else if matchesId(cid, customId(embedPrefix, "clear-overrides")) {
+		peek, _ := peekId(cid)
+		c.onClearOverrides(s, i, peek)
+	} else if matchesId(cid, customId(embedPrefix, "capture-template")) {
+		peek, _ := peekId(cid)
+		c.onCaptureTemplate(s, i, peek)
+	}
+}
+
+func (c *EmbedCommand) onRevealPassword(s *discordgo.Session, i *discordgo.InteractionCreate, sid string) {
+	if !canRevealSecrets(c.config, i.Interaction) {
+		ErrorResponse(s, i.Interaction, "You are not allowed to reveal the server password.")
+		return
+	}
+	server, err := c.servers.Find(sid)
+	if err != nil {
+		c.logger.Error("find-server", "error", err)
+		storageErrorResponse(s, i.Interaction, "Error trying to find server with ID "+sid+".", err)
+		return
+	}
+	if server == nil {
+		ErrorResponse(s, i.Interaction, "Could not find server with ID "+sid+".")
+		return
+	}
+	hc, err := hostingClient(*server)
+	if err != nil {
+		ErrorResponse(s, i.Interaction, "Could not fetch the current server password. Error: "+err.Error())
+		return
+	}
+	ctx, cancel := operationContext(i.Interaction)
+	defer cancel()
+	si, err := hc.ServerInfo(ctx)
+	if err != nil {
+		c.logger.Error("server-info", "error", err)
+		ErrorResponse(s, i.Interaction, "Could not fetch the current server password. "+resilience.Describe(err))
+		return
+	}
+	fields := []*discordgo.MessageEmbedField{{
+		Name:  "Current Server Password",
+		Value: "||" + valOrNotSet(si.Password) + "||",
+	}}
+	if server.PendingUpdate != nil && server.PendingUpdate.ServerPassword != "" {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  "New Server Password",
+			Value: "||" + server.PendingUpdate.ServerPassword + "||",
+		})
+	}
+	c.logger.Info("reveal-server-password", "server", sid, "user", i.Member.User.ID)
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+			Embeds: []*discordgo.MessageEmbed{{
+				Title:       server.Name,
+				Description: "Only you can see this message.",
+				Color:       ColorDarkGrey,
+				Fields:      fields,
+			}},
+		},
+	})
+	if err != nil {
+		c.logger.Error("send-response", "error", err)
 	}
 }
 
@@ -58,12 +130,16 @@ func (c *EmbedCommand) onSetNamePassword(s *discordgo.Session, i *discordgo.Inte
 	server, err := c.servers.Find(sid)
 	if err != nil {
 		c.logger.Error("find-server", "error", err)
-		ErrorResponse(s, i.Interaction, "Error trying to find server with ID "+sid+". Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "Error trying to find server with ID "+sid+".", err)
 		return
 	}
 	if server == nil {
 		c.logger.Error("find-server", "error", err)
-		ErrorResponse(s, i.Interaction, "Could not find server with ID "+sid+". Error: "+err.Error())
+		ErrorResponse(s, i.Interaction, "Could not find server with ID "+sid+".")
+		return
+	}
+	if !server.HostingConfigured() {
+		ErrorResponse(s, i.Interaction, "The name and password of this server can't be changed, as no hosting provider credentials are set.")
 		return
 	}
 
@@ -80,10 +156,12 @@ func (c *EmbedCommand) onSetNamePassword(s *discordgo.Session, i *discordgo.Inte
 			Components: []discordgo.MessageComponent{
 				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
 					discordgo.TextInput{
-						Label:    "Name",
-						Value:    serverName,
-						CustomID: "name",
-						Style:    discordgo.TextInputShort,
+						Label:       "Name",
+						Value:       serverName,
+						CustomID:    "name",
+						Style:       discordgo.TextInputShort,
+						Placeholder: "Leave empty to use the server name template",
+						MaxLength:   resources.MaxServerNameLength,
 					},
 				}},
 				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
@@ -94,6 +172,15 @@ func (c *EmbedCommand) onSetNamePassword(s *discordgo.Session, i *discordgo.Inte
 						Style:    discordgo.TextInputShort,
 					},
 				}},
+				discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+					discordgo.TextInput{
+						Label:       "Name template variables (key=value per line)",
+						Value:       resources.FormatNameVariables(server.NameVariables),
+						CustomID:    "name-variables",
+						Style:       discordgo.TextInputParagraph,
+						Placeholder: "region=EU",
+					},
+				}},
 			},
 			CustomID: customId(embedPrefix, "confirm-name-password", sid),
 		},
@@ -116,16 +203,16 @@ func (c *EmbedCommand) onSelectServer(s *discordgo.Session, i *discordgo.Interac
 	server, err := c.servers.Find(sid)
 	if err != nil {
 		c.logger.Error("find-server", "error", err)
-		ErrorResponse(s, i.Interaction, "Error trying to find server with ID "+sid+". Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "Error trying to find server with ID "+sid+".", err)
 		return
 	}
 	if server == nil {
 		c.logger.Error("find-server", "error", err)
-		ErrorResponse(s, i.Interaction, "Could not find server with ID "+sid+". Error: "+err.Error())
+		ErrorResponse(s, i.Interaction, "Could not find server with ID "+sid+".")
 		return
 	}
-	if server.TCAdminCredentials == nil || server.CRConCredentials == nil {
-		ErrorResponse(s, i.Interaction, "The server misses some credentials and can therefore no yet managed by this tool.")
+	if !server.RConConfigured() {
+		ErrorResponse(s, i.Interaction, "The server misses the CRCon or RCON credentials and can therefore not yet be managed by this tool.")
 		return
 	}
 
@@ -137,10 +224,12 @@ func (c *EmbedCommand) onSelectServer(s *discordgo.Session, i *discordgo.Interac
 		}
 	}
 
-	embeds, components, err := serverEmbed(c.templates, *server)
+	ctx, cancel := operationContext(i.Interaction)
+	defer cancel()
+	embeds, components, err := serverEmbed(ctx, c.templates, *server)
 	if err != nil {
 		c.logger.Error("create-message-embeds", "error", err)
-		ErrorResponse(s, i.Interaction, "There was an error creating the message components. Error: "+err.Error())
+		ErrorResponse(s, i.Interaction, "There was an error creating the message components. "+resilience.Describe(err))
 		return
 	}
 	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
@@ -156,11 +245,11 @@ func (c *EmbedCommand) onSelectTemplate(s *discordgo.Session, i *discordgo.Inter
 	server, err := c.servers.Find(sid)
 	if err != nil {
 		c.logger.Error("find-server", "error", err)
-		ErrorResponse(s, i.Interaction, "Error trying to find server with ID "+sid+". Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "Error trying to find server with ID "+sid+".", err)
 		return
 	}
 	if server == nil {
-		ErrorResponse(s, i.Interaction, "Could not find server with ID "+sid+". Error: "+err.Error())
+		ErrorResponse(s, i.Interaction, "Could not find server with ID "+sid+".")
 		return
 	}
 
@@ -168,11 +257,11 @@ func (c *EmbedCommand) onSelectTemplate(s *discordgo.Session, i *discordgo.Inter
 	template, err := c.templates.Find(tplId)
 	if err != nil {
 		c.logger.Error("find-template", "error", err)
-		ErrorResponse(s, i.Interaction, "Error trying to find template with ID "+tplId+". Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "Error trying to find template with ID "+tplId+".", err)
 		return
 	}
 	if template == nil {
-		ErrorResponse(s, i.Interaction, "Could not find server with ID "+tplId+". Error: "+err.Error())
+		ErrorResponse(s, i.Interaction, "Could not find template with ID "+tplId+".")
 		return
 	}
 	if server.PendingUpdate == nil {
@@ -183,14 +272,16 @@ func (c *EmbedCommand) onSelectTemplate(s *discordgo.Session, i *discordgo.Inter
 	err = c.servers.Save(*server)
 	if err != nil {
 		c.logger.Error("save-server", "error", err)
-		ErrorResponse(s, i.Interaction, "Error saving server. Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "Error saving server.", err)
 		return
 	}
 
-	embeds, components, err := serverEmbed(c.templates, *server)
+	ctx, cancel := operationContext(i.Interaction)
+	defer cancel()
+	embeds, components, err := serverEmbed(ctx, c.templates, *server)
 	if err != nil {
 		c.logger.Error("create-message-embeds", "error", err)
-		ErrorResponse(s, i.Interaction, "There was an error creating the message components. Error: "+err.Error())
+		ErrorResponse(s, i.Interaction, "There was an error creating the message components. "+resilience.Describe(err))
 		return
 	}
 	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
@@ -216,10 +307,10 @@ func (c *EmbedCommand) onSaveRestart(s *discordgo.Session, i *discordgo.Interact
 	server, err := c.servers.Find(sid)
 	if err != nil {
 		c.logger.Error("find-server", "error", err)
-		ErrorResponse(s, i.Interaction, "Error trying to find server with ID "+sid+". Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "Error trying to find server with ID "+sid+".", err)
 		return
 	}
-	if server == nil || server.CRConCredentials == nil || server.TCAdminCredentials == nil {
+	if server == nil || !server.RConConfigured() {
 		ErrorResponse(s, i.Interaction, "Could not find server with ID "+sid+".")
 		return
 	}
@@ -230,7 +321,7 @@ func (c *EmbedCommand) onSaveRestart(s *discordgo.Session, i *discordgo.Interact
 	template, err := c.templates.Find(server.PendingUpdate.TemplateId)
 	if err != nil {
 		c.logger.Error("find-template", "error", err)
-		ErrorResponse(s, i.Interaction, "Error trying to find template with ID "+server.PendingUpdate.TemplateId+". Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "Error trying to find template with ID "+server.PendingUpdate.TemplateId+".", err)
 		return
 	}
 	if template == nil {
@@ -238,54 +329,54 @@ func (c *EmbedCommand) onSaveRestart(s *discordgo.Session, i *discordgo.Interact
 		return
 	}
 
-	var errors []error
-	ctx := context.Background()
-	cc := crconClient(*server.CRConCredentials)
-	config := crcon.AutoBroadcastConfig{Enabled: true, Randomize: false}
-	for _, message := range template.BroadcastMessage {
-		config.Messages = append(config.Messages, crcon.BroadcastMessage{
-			TimeSec: message.Time,
-			Message: message.Message,
-		})
-	}
-	err = cc.SetAutoBroadcastConfig(ctx, config)
-	if err != nil {
-		errors = append(errors, fmt.Errorf("updating Auto-Broadcast: %w", err))
-	}
-	err = cc.SetWelcomeMessage(ctx, template.WelcomeMessage)
-	if err != nil {
-		errors = append(errors, fmt.Errorf("updating Welcome message: %w", err))
-	}
-	err = cc.SetTeamSwitchCooldown(ctx, template.TeamSwitchCooldown)
+	effective, _, err := serverTemplate(c.templates, *server, *template)
 	if err != nil {
-		errors = append(errors, fmt.Errorf("updating Team-Switch-Cooldown: %w", err))
-	}
-	err = cc.SetAutoBalanceThreshold(ctx, template.AutoBalanceThreshold)
-	if err != nil {
-		errors = append(errors, fmt.Errorf("updating Auto-Balance threshold: %w", err))
-	}
-	err = cc.SetProfanities(ctx, template.ProfanityFilter)
-	if err != nil {
-		errors = append(errors, fmt.Errorf("updating Profanities: %w", err))
+		ErrorResponse(s, i.Interaction, "The settings of the template can not be resolved. Error: "+err.Error())
+		return
 	}
 
-	tc := tcadminClient(*server.TCAdminCredentials)
-	err = tc.SetServerInfo(server.TCAdminCredentials.ServiceId, server.PendingUpdate.ServerName, server.PendingUpdate.ServerPassword)
-	if err != nil {
-		errors = append(errors, fmt.Errorf("updating Server name and password: %w", err))
-	}
-	if err == nil && server.PendingUpdate.RequiresRestart() {
-		_, err = tc.Restart(server.TCAdminCredentials.ServiceId)
+	ctx, cancel := operationContext(i.Interaction)
+	defer cancel()
+	cc := crconClient(*server)
+	applied, unapplied, errors := applyTemplate(ctx, cc, effective)
+
+	if server.HostingConfigured() {
+		name, err := nameOnApply(*server, template.Name, effective.ServerNameTemplate, time.Now())
+		if err != nil {
+			// the name is left unchanged rather than set to a partially rendered one
+			errors = append(errors, fmt.Errorf("rendering server name: %w", err))
+		}
+		hc, err := hostingClient(*server)
+		var current string
+		changed := false
+		if err == nil {
+			current, changed, err = updateServerInfo(ctx, hc, name, server.PendingUpdate.ServerPassword)
+		}
 		if err != nil {
-			errors = append(errors, fmt.Errorf("restarting server: %w", err))
+			errors = append(errors, fmt.Errorf("updating Server name and password: %w", err))
+		} else if name != "" {
+			applied.ServerNameTemplate = current
+		}
+		if err == nil && changed {
+			err = hc.Restart(ctx)
+			if err != nil {
+				errors = append(errors, fmt.Errorf("restarting server: %w", err))
+			}
 		}
 	}
 
 	server.PendingUpdate = nil
+	server.AppliedTemplate = &resources.AppliedTemplate{
+		TemplateId: template.TemplateId,
+		Version:    template.Version,
+		AppliedAt:  time.Now(),
+		Values:     applied,
+		Unapplied:  unapplied,
+	}
 	err = c.servers.Save(*server)
 	if err != nil {
 		c.logger.Error("save-server", "error", err)
-		ErrorResponse(s, i.Interaction, "Error saving server. Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "Error saving server.", err)
 		return
 	}
 
@@ -293,7 +384,7 @@ func (c *EmbedCommand) onSaveRestart(s *discordgo.Session, i *discordgo.Interact
 	if len(errors) != 0 {
 		message = "Some settings could not be updated. Any not mentioned setting was made successfully. Errors:\n\n"
 		for _, e := range errors {
-			message += "* " + e.Error() + "\n"
+			message += "* " + e.Error() + "\n  " + resilience.Explain(e) + "\n"
 		}
 	}
 	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
@@ -310,19 +401,21 @@ func (c *EmbedCommand) onRefresh(s *discordgo.Session, i *discordgo.InteractionC
 	server, err := c.servers.Find(sid)
 	if err != nil {
 		c.logger.Error("find-server", "error", err)
-		ErrorResponse(s, i.Interaction, "Error trying to find server with ID "+sid+". Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "Error trying to find server with ID "+sid+".", err)
 		return
 	}
 	if server == nil {
 		c.logger.Error("find-server", "error", err)
-		ErrorResponse(s, i.Interaction, "Could not find server with ID "+sid+". Error: "+err.Error())
+		ErrorResponse(s, i.Interaction, "Could not find server with ID "+sid+".")
 		return
 	}
 
-	embeds, components, err := serverEmbed(c.templates, *server)
+	ctx, cancel := operationContext(i.Interaction)
+	defer cancel()
+	embeds, components, err := serverEmbed(ctx, c.templates, *server)
 	if err != nil {
 		c.logger.Error("create-message-embeds", "error", err)
-		ErrorResponse(s, i.Interaction, "There was an error creating the message components. Error: "+err.Error())
+		ErrorResponse(s, i.Interaction, "There was an error creating the message components. "+resilience.Describe(err))
 		return
 	}
 	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
@@ -342,41 +435,62 @@ func (c *EmbedCommand) OnModalSubmit(s *discordgo.Session, i *discordgo.Interact
 	peek, _ := peekId(id)
 	if matchesId(id, customId(embedPrefix, "confirm-name-password")) {
 		c.onConfirmNamePassword(s, i, peek)
+	} else if matchesId(id, customId(embedPrefix, "confirm-override")) {
+		c.onConfirmOverride(s, i, peek)
 	}
 }
 
 func (c *EmbedCommand) onConfirmNamePassword(s *discordgo.Session, i *discordgo.InteractionCreate, sid string) {
 	var d setNamePasswordData
 	if err := marshaller.Unmarshal(i.ModalSubmitData().Components, &d); err != nil {
-		c.logger.Error("parse-data", err)
+		c.logger.Error("parse-data", "error", err)
 		ErrorResponse(s, i.Interaction, "Unknown error: "+err.Error())
 		return
 	}
 	server, err := c.servers.Find(sid)
 	if err != nil {
 		c.logger.Error("get-server", "error", err)
-		ErrorResponse(s, i.Interaction, "Could not find server with ID "+sid+": "+err.Error())
+		storageErrorResponse(s, i.Interaction, "Could not find server with ID "+sid+".", err)
 		return
 	}
 	if server == nil {
 		ErrorResponse(s, i.Interaction, "Could not find server with ID "+sid)
 		return
 	}
+	if !server.HostingConfigured() {
+		ErrorResponse(s, i.Interaction, "The name and password of this server can't be changed, as no hosting provider credentials are set.")
+		return
+	}
+
+	vars, err := resources.ParseNameVariables(d.NameVariables)
+	if err != nil {
+		ErrorResponse(s, i.Interaction, "The provided values are not valid. Error: "+err.Error())
+		return
+	}
+	if d.Name != "" {
+		if err := resources.ValidateServerName(d.Name); err != nil {
+			ErrorResponse(s, i.Interaction, "The provided values are not valid. Error: "+err.Error())
+			return
+		}
+	}
 
 	if server.PendingUpdate == nil {
 		server.PendingUpdate = &resources.ServerUpdate{}
 	}
 	server.PendingUpdate.ServerName = d.Name
 	server.PendingUpdate.ServerPassword = d.Password
+	server.NameVariables = vars
 	if err := c.servers.Save(*server); err != nil {
 		c.logger.Error("save-server", "error", err)
-		ErrorResponse(s, i.Interaction, "Couldn't save server data. Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "Couldn't save server data.", err)
 		return
 	}
-	embeds, components, err := serverEmbed(c.templates, *server)
+	ctx, cancel := operationContext(i.Interaction)
+	defer cancel()
+	embeds, components, err := serverEmbed(ctx, c.templates, *server)
 	if err != nil {
 		c.logger.Error("create-message-embeds", "error", err)
-		ErrorResponse(s, i.Interaction, "There was an error creating the message components. Error: "+err.Error())
+		ErrorResponse(s, i.Interaction, "There was an error creating the message components. "+resilience.Describe(err))
 		return
 	}
 	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
@@ -394,3 +508,118 @@ func (c *EmbedCommand) onConfirmNamePassword(s *discordgo.Session, i *discordgo.
 func (c *EmbedCommand) CanHandle(customId string) bool {
 	return matchesId(customId, embedPrefix)
 }
+
+// applyTemplate applies the settings of the template, except the server name, to the game server. It returns the
+// values which were applied, to detect later changes of the settings, and the settings which could not be applied.
+func applyTemplate(ctx context.Context, cc CRCon, t resources.Template) (applied resources.Template, failed []string, errs []error) {
+	r := &applyResult{}
+	config := crcon.AutoBroadcastConfig{Enabled: true, Randomize: false}
+	for _, message := range t.BroadcastMessage {
+		config.Messages = append(config.Messages, crcon.BroadcastMessage{
+			TimeSec: message.Time,
+			Message: message.Message,
+		})
+	}
+	r.record(resources.SettingBroadcastMessages, "Auto-Broadcast", cc.SetAutoBroadcastConfig(ctx, config))
+	r.record(resources.SettingWelcomeMessage, "Welcome message", cc.SetWelcomeMessage(ctx, t.WelcomeMessage))
+	r.record(resources.SettingProfanityFilter, "Profanities", cc.SetProfanities(ctx, t.ProfanityFilter))
+	applyServerSettings(ctx, cc, t, r)
+	rotation, err := applyMaps(ctx, cc, t)
+	if rotation == nil && len(err) != 0 {
+		r.failed = append(r.failed, resources.SettingMapRotation)
+	}
+	r.errs = append(r.errs, err...)
+
+	applied = t
+	applied.ServerNameTemplate = ""
+	applied.MapRotation = rotation
+	return applied, r.failed, r.errs
+}
+
+// applyResult collects the errors of applying a template and the settings which were not applied because of them.
+type applyResult struct {
+	errs   []error
+	failed []string
+}
+
+func (r *applyResult) record(setting, name string, err error) {
+	if err != nil {
+		r.errs = append(r.errs, fmt.Errorf("updating %s: %w", name, err))
+		r.failed = append(r.failed, setting)
+	}
+}
+
+// applyServerSettings sets the optional server settings of the template, settings which are not set in the template
+// are left unchanged.
+func applyServerSettings(ctx context.Context, cc CRCon, t resources.Template, r *applyResult) {
+	if t.TeamSwitchCooldown != nil {
+		r.record(resources.SettingTeamSwitchCooldown, "Team-Switch-Cooldown", cc.SetTeamSwitchCooldown(ctx, *t.TeamSwitchCooldown))
+	}
+	if t.AutoBalanceThreshold != nil {
+		r.record(resources.SettingAutoBalanceThreshold, "Auto-Balance threshold", cc.SetAutoBalanceThreshold(ctx, *t.AutoBalanceThreshold))
+	}
+	if t.AutoBalanceEnabled != nil {
+		r.record(resources.SettingAutoBalanceEnabled, "Auto-Balance enabled", cc.SetAutoBalanceEnabled(ctx, *t.AutoBalanceEnabled))
+	}
+	if t.IdleAutoKickTime != nil {
+		r.record(resources.SettingIdleAutoKickTime, "Idle autokick time", cc.SetIdleAutoKickTime(ctx, *t.IdleAutoKickTime))
+	}
+	if t.MaxPingAutoKick != nil {
+		r.record(resources.SettingMaxPingAutoKick, "Max ping autokick", cc.SetMaxPingAutoKick(ctx, *t.MaxPingAutoKick))
+	}
+	if t.QueueLength != nil {
+		r.record(resources.SettingQueueLength, "Queue length", cc.SetQueueLength(ctx, *t.QueueLength))
+	}
+	if t.VipSlots != nil {
+		r.record(resources.SettingVipSlots, "VIP slots", cc.SetVipSlots(ctx, *t.VipSlots))
+	}
+	if t.VoteKickEnabled != nil {
+		r.record(resources.SettingVoteKickEnabled, "Vote-Kick enabled", cc.SetVoteKickEnabled(ctx, *t.VoteKickEnabled))
+	}
+}
+
+// applyMaps replaces the map rotation of the server and switches to the starting map of the template. Templates with a
+// game mode and without a starting map switch to the first map of the rotation only when the current map is of another
+// game mode, so that the game mode changes immediately without restarting a running match.
+func applyMaps(ctx context.Context, cc CRCon, t resources.Template) (rotation []resources.Map, errs []error) {
+	var available []resources.Map
+	if len(t.MapRotation) == 0 && t.GameMode != "" {
+		maps, err := cc.Maps(ctx)
+		if err != nil {
+			return nil, []error{fmt.Errorf("listing the maps of game mode %s: %w", t.GameMode, err)}
+		}
+		for _, m := range maps {
+			available = append(available, fromCRConMap(m))
+		}
+	}
+	rotation, err := t.Rotation(available)
+	if err != nil {
+		return nil, []error{fmt.Errorf("updating Map rotation: %w", err)}
+	}
+	if len(rotation) == 0 {
+		return
+	}
+	var ids []string
+	for _, m := range rotation {
+		ids = append(ids, m.Id)
+	}
+	if err := cc.SetMapRotation(ctx, ids); err != nil {
+		return nil, []error{fmt.Errorf("updating Map rotation: %w", err)}
+	}
+	starting := t.StartingMap
+	if starting == "" && t.GameMode != "" {
+		state, err := cc.GameState(ctx)
+		if err != nil {
+			return rotation, []error{fmt.Errorf("reading the current map: %w", err)}
+		}
+		if state.Map.GameMode != string(t.GameMode) {
+			starting = rotation[0].Id
+		}
+	}
+	if starting != "" {
+		if err := cc.SwitchMap(ctx, starting); err != nil {
+			errs = append(errs, fmt.Errorf("switching to the starting map: %w", err))
+		}
+	}
+	return
+}
@@ -2,15 +2,19 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/bwmarrin/discordgo"
 	"github.com/floriansw/go-discordgo-utils/util"
 	"github.com/floriansw/hll-discord-server-watcher/internal"
+	"github.com/floriansw/hll-discord-server-watcher/internal/hosting"
 	"github.com/floriansw/hll-discord-server-watcher/resources"
+	"slices"
 	"strconv"
+	"time"
 )
 
-func serversEmbed(s internal.Storage[resources.Server]) (embeds []*discordgo.MessageEmbed, buttons []discordgo.MessageComponent, err error) {
+func serversEmbed(s internal.Repository[resources.Server]) (embeds []*discordgo.MessageEmbed, buttons []discordgo.MessageComponent, err error) {
 	buttons = append(buttons, discordgo.Button{
 		Emoji:    &discordgo.ComponentEmoji{ID: "1283790096461594655"},
 		Style:    discordgo.SecondaryButton,
@@ -18,19 +22,21 @@ func serversEmbed(s internal.Storage[resources.Server]) (embeds []*discordgo.Mes
 		CustomID: customId(createEmbedPrefix, "refresh"),
 	})
 
-	sl, err := s.List()
+	sl, err := s.ListSummaries()
 	if err != nil {
 		return nil, nil, err
 	}
 	var servers []discordgo.SelectMenuOption
-	for _, id := range sl {
-		sd, err := s.Find(id)
-		if err != nil {
-			return nil, nil, err
+	for _, sd := range sl {
+		// select menu descriptions are limited to 100 characters
+		d := []rune(sd.Description)
+		if len(d) > 100 {
+			d = append(d[:99], '…')
 		}
 		servers = append(servers, discordgo.SelectMenuOption{
-			Label: sd.Name,
-			Value: sd.ServerId,
+			Label:       sd.Name,
+			Value:       sd.Id,
+			Description: string(d),
 		})
 	}
 
@@ -50,41 +56,64 @@ func serversEmbed(s internal.Storage[resources.Server]) (embeds []*discordgo.Mes
 	}, nil
 }
 
-func serverEmbed(t internal.Storage[resources.Template], s resources.Server) (embeds []*discordgo.MessageEmbed, buttons []discordgo.MessageComponent, err error) {
-	tc := tcadminClient(*s.TCAdminCredentials)
-	si, err := tc.ServerInfo(s.TCAdminCredentials.ServiceId)
-
-	if err != nil {
-		return nil, nil, err
+func serverEmbed(ctx context.Context, t internal.Repository[resources.Template], s resources.Server) (embeds []*discordgo.MessageEmbed, buttons []discordgo.MessageComponent, err error) {
+	// servers without hosting credentials can still be managed with templates, the name, password and restart of the
+	// server are then left to the host
+	hostingConfigured := s.HostingConfigured()
+	si := &hosting.ServerInfo{}
+	var status hosting.Status
+	if hostingConfigured {
+		hc, err := hostingClient(s)
+		if err != nil {
+			return nil, nil, err
+		}
+		si, err = hc.ServerInfo(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		status, err = hc.Status(ctx)
+		if err != nil && !errors.Is(err, hosting.ErrUnsupported) {
+			return nil, nil, err
+		}
 	}
 
-	cc := crconClient(*s.CRConCredentials)
-	pids, err := cc.PlayerIds(context.Background())
+	cc := crconClient(s)
+	pids, err := cc.PlayerIds(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	sl, err := t.List()
+	sl, err := t.ListSummaries()
 	if err != nil {
 		return nil, nil, err
 	}
+	pu := resources.ServerUpdate{}
+	if s.PendingUpdate != nil {
+		pu = *s.PendingUpdate
+	}
 	var templates []discordgo.SelectMenuOption
-	for _, id := range sl {
-		sd, err := t.Find(id)
-		if err != nil {
+	for _, sd := range sl {
+		o := discordgo.SelectMenuOption{
+			Label:   sd.Name,
+			Value:   sd.Id,
+			Default: sd.Id == pu.TemplateId,
+		}
+		if s.AppliedTemplate != nil && sd.Id == s.AppliedTemplate.TemplateId {
+			o.Description = fmt.Sprintf("Running (version %d)", s.AppliedTemplate.Version)
+		}
+		templates = append(templates, o)
+	}
+	var running *resources.Template
+	if s.AppliedTemplate != nil {
+		if running, err = t.Find(s.AppliedTemplate.TemplateId); err != nil {
 			return nil, nil, err
 		}
-		templates = append(templates, discordgo.SelectMenuOption{
-			Label: sd.Name,
-			Value: sd.TemplateId,
-		})
 	}
 
-	pu := resources.ServerUpdate{}
-	if s.PendingUpdate != nil {
-		pu = *s.PendingUpdate
-	}
 	templateName := "not set"
+	if s.AppliedTemplate != nil {
+		templateName = "not changed"
+	}
 	for _, template := range templates {
 		if template.Value == pu.TemplateId {
 			templateName = template.Label
@@ -94,41 +123,96 @@ func serverEmbed(t internal.Storage[resources.Template], s resources.Server) (em
 	if pu.ServerName != "" {
 		serverName = fmt.Sprintf("~~%s~~ -> %s", serverName, pu.ServerName)
 	}
-	serverPassword := si.Password
+	serverPassword := maskSecret(si.Password)
 	if pu.ServerPassword != "" {
-		serverPassword = fmt.Sprintf("~~%s~~ -> %s", serverPassword, pu.ServerPassword)
+		serverPassword = fmt.Sprintf("~~%s~~ -> %s", serverPassword, maskSecret(pu.ServerPassword))
+	}
+	description := "See the server details below. You can change details, which are only applied when you confirm the changes. The server might then be restarted!"
+	fields := []*discordgo.MessageEmbedField{{
+		Name:   "Server Name",
+		Value:  serverName,
+		Inline: true,
+	}, {
+		Name:   "Server Password",
+		Value:  serverPassword,
+		Inline: true,
+	}}
+	saveLabel := "Save and restart"
+	if !hostingConfigured {
+		description = "See the server details below. You can select a template, which is only applied when you confirm the changes."
+		fields = []*discordgo.MessageEmbedField{{
+			Name:  "Server Name & Password",
+			Value: "No hosting provider credentials are set for this server. The name and password can't be changed and the server can't be restarted by this bot, please use the panel of your host instead.",
+		}}
+		saveLabel = "Save"
 	}
 	embeds = append(embeds, &discordgo.MessageEmbed{
 		Title:       s.Name,
-		Description: "See the server details below. You can change details, which are only applied when you confirm the changes. The server might then be restarted!",
+		Description: description,
 		Color:       util.ColorDarkBlue,
-		Fields: []*discordgo.MessageEmbedField{{
+		Fields: append([]*discordgo.MessageEmbedField{{
 			Name:  "Player Count",
 			Value: strconv.Itoa(len(pids)),
+		}, {
+			Name:  "Running Template",
+			Value: appliedTemplateSummary(s.AppliedTemplate, running),
 		}, {
 			Name:  "Template",
 			Value: templateName,
 		}, {
-			Name:   "Server Name",
-			Value:  serverName,
-			Inline: true,
-		}, {
-			Name:   "Server Password",
-			Value:  serverPassword,
-			Inline: true,
-		}},
+			Name:  "Overrides",
+			Value: overridesSummary(s),
+		}}, fields...),
 	})
+	if pu.TemplateId != "" {
+		preview := "The selected template can not be found."
+		if next, err := t.Find(pu.TemplateId); err != nil {
+			return nil, nil, err
+		} else if next != nil {
+			if effective, sources, err := serverTemplate(t, s, *next); err != nil {
+				preview = "The settings of the template can not be resolved. Error: " + err.Error()
+			} else {
+				preview = changesPreview(effective, sources, s.AppliedTemplate)
+				if hostingConfigured && pu.ServerName == "" {
+					if name, err := nameOnApply(s, next.Name, effective.ServerNameTemplate, time.Now()); err != nil {
+						fields[0].Value = si.Name + "\n⚠️ " + err.Error()
+					} else if name != "" {
+						fields[0].Value = fmt.Sprintf("~~%s~~ -> %s\n*from the server name template*", si.Name, name)
+					}
+				}
+			}
+		}
+		embeds[0].Fields = append(embeds[0].Fields, &discordgo.MessageEmbedField{
+			Name:  "Changes on apply",
+			Value: preview,
+		})
+	}
+	if status != "" {
+		embeds[0].Fields = slices.Insert(embeds[0].Fields, 1, &discordgo.MessageEmbedField{
+			Name:  "Status",
+			Value: string(status),
+		})
+	}
 	buttons = append(buttons, []discordgo.MessageComponent{
 		discordgo.Button{
-			Label:    "Save and restart",
+			Label:    saveLabel,
 			Style:    discordgo.PrimaryButton,
 			Disabled: pu.TemplateId == "",
 			CustomID: customId(embedPrefix, "save-restart", s.ServerId),
 		}, discordgo.Button{
 			Label:    "Set Name & Password",
 			Style:    discordgo.SecondaryButton,
-			Disabled: false,
+			Disabled: !hostingConfigured,
 			CustomID: customId(embedPrefix, "set-name-password", s.ServerId),
+		}, discordgo.Button{
+			Label:    "Reveal password to me",
+			Style:    discordgo.SecondaryButton,
+			Disabled: !hostingConfigured,
+			CustomID: customId(embedPrefix, "reveal-password", s.ServerId),
+		}, discordgo.Button{
+			Label:    "Save current settings as template",
+			Style:    discordgo.SecondaryButton,
+			CustomID: customId(embedPrefix, "capture-template", s.ServerId),
 		}, discordgo.Button{
 			Emoji:    &discordgo.ComponentEmoji{ID: "1283790096461594655"},
 			Style:    discordgo.SecondaryButton,
@@ -136,7 +220,7 @@ func serverEmbed(t internal.Storage[resources.Template], s resources.Server) (em
 			CustomID: customId(embedPrefix, "refresh", s.ServerId),
 		}}...)
 
-	return embeds, []discordgo.MessageComponent{
+	components := []discordgo.MessageComponent{
 		discordgo.ActionsRow{
 			Components: []discordgo.MessageComponent{
 				discordgo.SelectMenu{
@@ -149,5 +233,21 @@ func serverEmbed(t internal.Storage[resources.Template], s resources.Server) (em
 		discordgo.ActionsRow{
 			Components: buttons,
 		},
-	}, nil
+	}
+	return embeds, append(components, overrideButtons(s)...), nil
+}
+
+// appliedTemplateSummary describes the template the server runs. The current template is nil when it was deleted.
+func appliedTemplateSummary(a *resources.AppliedTemplate, current *resources.Template) string {
+	if a == nil {
+		return "Unknown, no template was applied through this bot yet"
+	}
+	if current == nil {
+		return fmt.Sprintf("%s (version %d, applied <t:%d:R>), the template was deleted", a.Values.Name, a.Version, a.AppliedAt.Unix())
+	}
+	res := fmt.Sprintf("%s (version %d, applied <t:%d:R>)", current.Name, a.Version, a.AppliedAt.Unix())
+	if current.Version > a.Version {
+		res += fmt.Sprintf("\nThe template was changed since, the latest version is %d.", current.Version)
+	}
+	return res
 }
@@ -0,0 +1,225 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	. "github.com/floriansw/go-discordgo-utils/util"
+	"github.com/floriansw/hll-discord-server-watcher/internal"
+	"github.com/floriansw/hll-discord-server-watcher/internal/hosting"
+	"github.com/floriansw/hll-discord-server-watcher/resources"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// HealthCheck periodically verifies the stored credentials of all servers and alerts when credentials stop working
+// or the permissions of a CRCon API key changed.
+type HealthCheck struct {
+	logger  *slog.Logger
+	config  *internal.Config
+	servers internal.Repository[resources.Server]
+}
+
+func NewHealthCheck(l *slog.Logger, c *internal.Config, s internal.Repository[resources.Server]) *HealthCheck {
+	return &HealthCheck{
+		logger:  l,
+		config:  c,
+		servers: s,
+	}
+}
+
+// Schedule runs the health check every configured interval until stop is closed. It returns immediately when the
+// health check is disabled.
+func (h *HealthCheck) Schedule(s *discordgo.Session, stop <-chan struct{}) {
+	if h.config.HealthCheck == nil || h.config.HealthCheck.IntervalMinutes <= 0 {
+		return
+	}
+	t := time.NewTicker(time.Duration(h.config.HealthCheck.IntervalMinutes) * time.Minute)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+			h.checkAll(s)
+		}
+	}
+}
+
+func (h *HealthCheck) checkAll(s *discordgo.Session) {
+	summaries, err := h.servers.ListSummaries()
+	if err != nil {
+		h.logger.Error("health-check-list-servers", "error", err)
+		return
+	}
+	for _, summary := range summaries {
+		if !summary.Credentials {
+			continue
+		}
+		id := summary.Id
+		server, err := h.servers.Find(id)
+		if err != nil || server == nil {
+			h.logger.Error("health-check-find-server", "server", id, "error", err)
+			continue
+		}
+		var alerts []string
+		if server.CRConCredentials != nil {
+			alerts = append(alerts, h.checkCRCon(server.CRConCredentials)...)
+		}
+		if server.RConCredentials != nil {
+			alerts = append(alerts, h.checkRCon(server.RConCredentials)...)
+		}
+		if server.TCAdminCredentials != nil {
+			alerts = append(alerts, h.checkTCAdmin(server.ServerId, server.TCAdminCredentials)...)
+		}
+		if server.PterodactylCredentials != nil {
+			alerts = append(alerts, h.checkPterodactyl(server.PterodactylCredentials)...)
+		}
+		if err := h.saveHealth(*server); err != nil {
+			h.logger.Error("health-check-save-server", "server", id, "error", err)
+		}
+		if len(alerts) == 0 {
+			continue
+		}
+		h.logger.Info("health-check-alert", "server", id, "alerts", len(alerts))
+		err = sendAlert(s, h.config, []*discordgo.MessageEmbed{{
+			Title:       "Credentials of " + server.Name,
+			Description: strings.Join(alerts, "\n\n"),
+			Color:       ColorDarkRed,
+		}}, nil)
+		if err != nil {
+			h.logger.Error("health-check-send-alert", "server", id, "error", err)
+		}
+	}
+}
+
+func (h *HealthCheck) checkCRCon(c *resources.CRConCredentials) (alerts []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), operationTimeout)
+	defer cancel()
+	p, err := newCRConClient(*c).OwnPermissions(ctx)
+	if err != nil {
+		if !c.Health.Failing() {
+			alerts = append(alerts, "The CRCon API key stopped working. Error: "+err.Error())
+		}
+		c.Health.LastError = err.Error()
+		return
+	}
+	if c.Health.Failing() {
+		alerts = append(alerts, "The CRCon API key works again.")
+	}
+	now := time.Now()
+	c.Health = resources.CredentialsHealth{LastSuccessAt: &now}
+
+	status := internal.CheckPermissions(p)
+	if c.PermissionStatus != nil && !samePermissions(*c.PermissionStatus, status) {
+		alerts = append(alerts, "The permissions of the CRCon API key changed.\n\n"+permissionReport(status))
+	}
+	c.PermissionStatus = &status
+	return
+}
+
+func (h *HealthCheck) checkRCon(c *resources.RConCredentials) (alerts []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), operationTimeout)
+	defer cancel()
+	_, err := newRConClient(*c).OwnPermissions(ctx)
+	if err != nil {
+		if !c.Health.Failing() {
+			alerts = append(alerts, "The RCON password stopped working. Error: "+err.Error())
+		}
+		c.Health.LastError = err.Error()
+		return
+	}
+	if c.Health.Failing() {
+		alerts = append(alerts, "The RCON password works again.")
+	}
+	now := time.Now()
+	c.Health = resources.CredentialsHealth{LastSuccessAt: &now}
+	return
+}
+
+func (h *HealthCheck) checkTCAdmin(serverId string, c *resources.TCAdminCredentials) (alerts []string) {
+	_, err := tcadminClient(serverId, *c).ServerInfo(c.ServiceId)
+	if err != nil {
+		if !c.Health.Failing() {
+			alerts = append(alerts, "The TCAdmin credentials stopped working. Error: "+err.Error())
+		}
+		c.Health.LastError = err.Error()
+		return
+	}
+	if c.Health.Failing() {
+		alerts = append(alerts, "The TCAdmin credentials work again.")
+	}
+	now := time.Now()
+	c.Health = resources.CredentialsHealth{LastSuccessAt: &now}
+	return
+}
+
+func (h *HealthCheck) checkPterodactyl(c *resources.PterodactylCredentials) (alerts []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), operationTimeout)
+	defer cancel()
+	_, err := hosting.NewPterodactyl(http.Client{Timeout: backendTimeout}, *c).ServerInfo(ctx)
+	if err != nil {
+		if !c.Health.Failing() {
+			alerts = append(alerts, "The Pterodactyl credentials stopped working. Error: "+err.Error())
+		}
+		c.Health.LastError = err.Error()
+		return
+	}
+	if c.Health.Failing() {
+		alerts = append(alerts, "The Pterodactyl credentials work again.")
+	}
+	now := time.Now()
+	c.Health = resources.CredentialsHealth{LastSuccessAt: &now}
+	return
+}
+
+// saveHealth stores the result of the checks on the latest version of the server. The check takes some time, in
+// which an admin might have changed the server, e.g. by setting new credentials.
+func (h *HealthCheck) saveHealth(checked resources.Server) error {
+	server, err := h.servers.Find(checked.ServerId)
+	if err != nil || server == nil {
+		return err
+	}
+	if c := server.CRConCredentials; c != nil && checked.CRConCredentials != nil && c.ApiUrl() == checked.CRConCredentials.ApiUrl() && c.ApiKey == checked.CRConCredentials.ApiKey {
+		c.Health = checked.CRConCredentials.Health
+		c.PermissionStatus = checked.CRConCredentials.PermissionStatus
+	}
+	if c := server.RConCredentials; c != nil && checked.RConCredentials != nil && c.Address() == checked.RConCredentials.Address() && c.Password == checked.RConCredentials.Password {
+		c.Health = checked.RConCredentials.Health
+	}
+	if c := server.TCAdminCredentials; c != nil && checked.TCAdminCredentials != nil && sameTCAdminCredentials(*c, *checked.TCAdminCredentials) {
+		c.Health = checked.TCAdminCredentials.Health
+	}
+	if c := server.PterodactylCredentials; c != nil && checked.PterodactylCredentials != nil && samePterodactylCredentials(*c, *checked.PterodactylCredentials) {
+		c.Health = checked.PterodactylCredentials.Health
+	}
+	return h.servers.Save(*server)
+}
+
+func sameTCAdminCredentials(a, b resources.TCAdminCredentials) bool {
+	a.Health, b.Health = resources.CredentialsHealth{}, resources.CredentialsHealth{}
+	return a == b
+}
+
+func samePterodactylCredentials(a, b resources.PterodactylCredentials) bool {
+	a.Health, b.Health = resources.CredentialsHealth{}, resources.CredentialsHealth{}
+	return a == b
+}
+
+func samePermissions(a, b resources.PermissionStatus) bool {
+	return a.Superuser == b.Superuser && slices.Equal(a.Missing, b.Missing) && slices.Equal(a.Extra, b.Extra)
+}
+
+// healthSummary is a one-line representation of the last health check of credentials.
+func healthSummary(h resources.CredentialsHealth) string {
+	if h.Failing() {
+		return "Failing: " + h.LastError
+	}
+	if h.LastSuccessAt == nil {
+		return "Not checked yet"
+	}
+	return fmt.Sprintf("Last successful check <t:%d:R>", h.LastSuccessAt.Unix())
+}
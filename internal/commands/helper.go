@@ -2,9 +2,15 @@ package commands
 
 import (
 	"context"
+	"errors"
+	"github.com/bwmarrin/discordgo"
 	"github.com/floriansw/go-crcon"
+	. "github.com/floriansw/go-discordgo-utils/util"
 	"github.com/floriansw/go-tcadmin"
 	"github.com/floriansw/hll-discord-server-watcher/internal"
+	"github.com/floriansw/hll-discord-server-watcher/internal/crconapi"
+	"github.com/floriansw/hll-discord-server-watcher/internal/hosting"
+	"github.com/floriansw/hll-discord-server-watcher/internal/rcon"
 	"github.com/floriansw/hll-discord-server-watcher/resources"
 	"net/http"
 	"net/http/cookiejar"
@@ -19,6 +25,10 @@ func String(s string) *string {
 	return &s
 }
 
+func Bool(b bool) *bool {
+	return &b
+}
+
 func customId(components ...string) string {
 	return strings.Join(components, "#")
 }
@@ -43,23 +53,89 @@ func peekId(cid string) (peek string, rest string) {
 	}
 }
 
-type TCAdmin interface {
-	ServerInfo(serviceId string) (*tcadmin.ServerInfo, error)
-	SetServerInfo(serviceId string, name, pw string) error
-	Restart(serviceId string) (string, error)
+// autocompleteChoices converts the summaries into choices of an autocomplete response. Discord accepts 25 choices at
+// most, any further summary is dropped.
+func autocompleteChoices(l []resources.Summary) (choices []*discordgo.ApplicationCommandOptionChoice) {
+	for _, s := range l {
+		if len(choices) == 25 {
+			break
+		}
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  s.Name,
+			Value: s.Id,
+		})
+	}
+	return
+}
+
+// focusedValue returns the current input of the option the user is typing in.
+func focusedValue(i *discordgo.InteractionCreate) string {
+	for _, option := range i.ApplicationCommandData().Options {
+		if option.Focused {
+			if v, ok := option.Value.(string); ok {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+var errNoHostingCredentials = errors.New("the credentials of the hosting provider are not set")
+
+// tcadminPool is shared by all commands and background jobs, so that each server keeps its panel session.
+var tcadminPool = hosting.NewTCAdminPool(newTCAdminClient)
+
+func tcadminClient(serverId string, creds resources.TCAdminCredentials) hosting.TCAdminClient {
+	return tcadminPool.Client(serverId, creds)
 }
 
-func tcadminClient(creds resources.TCAdminCredentials) TCAdmin {
+func newTCAdminClient(creds resources.TCAdminCredentials) hosting.TCAdminClient {
+	gameId, modId, fileId := tcadminConfigIds(creds)
 	jar, _ := cookiejar.New(nil)
 	return tcadmin.NewClient(http.Client{
-		Jar: jar,
+		Timeout: backendTimeout,
+		Jar:     jar,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		},
-	}, creds.BaseUrl, internal.HLLGameId, internal.HLLModId, internal.HLLFileId, tcadmin.Credentials{Username: creds.Username, Password: creds.Password})
+	}, creds.PanelAddress(), gameId, modId, fileId, tcadmin.Credentials{Username: creds.Username, Password: creds.Password})
 }
 
+// tcadminConfigIds returns the game, mod and file IDs of the credentials, defaulting to the ones of Hell Let Loose.
+func tcadminConfigIds(creds resources.TCAdminCredentials) (gameId, modId, fileId string) {
+	if creds.GameId == "" {
+		return internal.HLLGameId, internal.HLLModId, internal.HLLFileId
+	}
+	return creds.GameId, creds.ModId, creds.FileId
+}
+
+// hostingClient returns the provider of the hosting the server is configured to use. Calls are retried and stopped
+// by the circuit breaker of the server.
+func hostingClient(s resources.Server) (hosting.Provider, error) {
+	var p hosting.Provider
+	switch s.Hosting() {
+	case resources.HostingPterodactyl:
+		if s.PterodactylCredentials == nil {
+			return nil, errNoHostingCredentials
+		}
+		p = hosting.NewPterodactyl(http.Client{Timeout: backendTimeout}, *s.PterodactylCredentials)
+	default:
+		if s.TCAdminCredentials == nil {
+			return nil, errNoHostingCredentials
+		}
+		p = hosting.NewTCAdmin(tcadminClient(s.ServerId, *s.TCAdminCredentials), s.TCAdminCredentials.ServiceId)
+	}
+	return &resilientProvider{provider: p, breaker: breakers.Get("hosting#" + s.ServerId)}, nil
+}
+
+// CRCon manages the game server, either through CRCon or RCON directly.
 type CRCon interface {
+	crconBasics
+	crconExtensions
+}
+
+// crconBasics are the calls implemented by the go-crcon client.
+type crconBasics interface {
 	SetTeamSwitchCooldown(ctx context.Context, minutes int) error
 	SetAutoBalanceThreshold(ctx context.Context, maxDiff int) error
 	SetProfanities(ctx context.Context, prof []string) error
@@ -68,9 +144,86 @@ type CRCon interface {
 	WelcomeMessage(ctx context.Context) (string, error)
 	ServerSettings(ctx context.Context) (crcon.ServerSettings, error)
 	PlayerIds(ctx context.Context) ([]string, error)
+	GameState(ctx context.Context) (crcon.GameState, error)
 	OwnPermissions(ctx context.Context) (crcon.OwnPermissions, error)
+	SwitchMap(ctx context.Context, id string) error
+	MapRotation(ctx context.Context) (crcon.MapRotation, error)
+}
+
+// crconExtensions are the calls the go-crcon client does not implement.
+type crconExtensions interface {
+	SetAutoBalanceEnabled(ctx context.Context, enabled bool) error
+	SetIdleAutoKickTime(ctx context.Context, minutes int) error
+	SetMaxPingAutoKick(ctx context.Context, ms int) error
+	SetQueueLength(ctx context.Context, length int) error
+	SetVipSlots(ctx context.Context, slots int) error
+	SetVoteKickEnabled(ctx context.Context, enabled bool) error
+	Maps(ctx context.Context) ([]crcon.Map, error)
+	SetMapRotation(ctx context.Context, ids []string) error
+	AutoBroadcastConfig(ctx context.Context) (crcon.AutoBroadcastConfig, error)
+	Profanities(ctx context.Context) ([]string, error)
 }
 
-func crconClient(creds resources.CRConCredentials) CRCon {
-	return crcon.NewClient(http.Client{}, creds.BaseUrl, crcon.Credentials{ApiKey: creds.ApiKey})
+// crconClient returns the client of the game server, calls are retried and stopped by the circuit breaker of the
+// server. CRCon is preferred, servers without CRCon are managed through RCON directly.
+func crconClient(s resources.Server) CRCon {
+	var c CRCon
+	if s.CRConCredentials != nil {
+		c = newCRConClient(*s.CRConCredentials)
+	} else {
+		c = newRConClient(*s.RConCredentials)
+	}
+	return &resilientCRCon{client: c, breaker: breakers.Get("crcon#" + s.ServerId)}
+}
+
+// newCRConClient returns a client without retries, e.g. to verify credentials before they are saved.
+func newCRConClient(creds resources.CRConCredentials) CRCon {
+	hc := http.Client{Timeout: backendTimeout}
+	return struct {
+		crconBasics
+		crconExtensions
+	}{
+		crcon.NewClient(hc, creds.ApiUrl(), crcon.Credentials{ApiKey: creds.ApiKey}),
+		crconapi.NewClient(hc, creds.ApiUrl(), creds.ApiKey),
+	}
+}
+
+// newRConClient returns a client connecting to the RCON interface of the game server directly.
+func newRConClient(creds resources.RConCredentials) CRCon {
+	return rcon.NewClient(creds.Address(), creds.Password)
+}
+
+// storageErrorResponse responds with the message and the error returned by a storage. Invalid IDs can only be the
+// result of crafted interaction values, these are answered with a generic message without technical details.
+func storageErrorResponse(s *discordgo.Session, i *discordgo.Interaction, msg string, err error) {
+	var ide *resources.InvalidIdError
+	if errors.As(err, &ide) {
+		ErrorResponse(s, i, "The provided ID is not valid. Please select the server or template from the provided choices.")
+		return
+	}
+	ErrorResponse(s, i, msg+" Error: "+err.Error())
+}
+
+// maskSecret hides a secret for display. The last characters of long secrets are kept, so that admins can tell
+// different API keys apart.
+func maskSecret(v string) string {
+	if v == "" {
+		return "not set"
+	}
+	if len(v) >= 16 {
+		return "`••••••••" + v[len(v)-4:] + "`"
+	}
+	return "`••••••••`"
+}
+
+// canRevealSecrets returns true if the member who created the interaction is allowed to see stored secrets in clear
+// text.
+func canRevealSecrets(c *internal.Config, i *discordgo.Interaction) bool {
+	if i.Member == nil {
+		return false
+	}
+	if i.Member.Permissions&discordgo.PermissionAdministrator != 0 {
+		return true
+	}
+	return c.CanRevealSecrets(i.Member.Roles)
 }
@@ -0,0 +1,354 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"github.com/floriansw/go-crcon"
+	"github.com/floriansw/go-discordgo-utils/marshaller"
+	. "github.com/floriansw/go-discordgo-utils/util"
+	"github.com/floriansw/hll-discord-server-watcher/internal"
+	"github.com/floriansw/hll-discord-server-watcher/internal/resilience"
+	"github.com/floriansw/hll-discord-server-watcher/resources"
+	"strings"
+)
+
+// sourceServer is the source of settings set in the overrides of a server.
+const sourceServer = "server override"
+
+// overrideModals are the modals of the template embed which are used to edit the overrides of a server.
+var overrideModals = map[string]ModalDefinition{
+	"messages":           messagesModal,
+	"broadcast-messages": broadcastMessagesModal,
+	"thresholds":         thresholdsModal,
+	"server-settings":    serverSettingsModal,
+	"profanity-filter":   profanityFilterModal,
+	"maps":               mapsModal,
+}
+
+type broadcastMessagesData struct {
+	Messages string `discordgo:"broadcast-messages"`
+}
+
+type mapsData struct {
+	Rotation    string `discordgo:"map-rotation"`
+	StartingMap string `discordgo:"starting-map"`
+	GameMode    string `discordgo:"game-mode"`
+}
+
+// broadcastMessagesModal edits the broadcast messages of the overrides of a server. Templates edit them with the
+// broadcast message commands instead.
+func broadcastMessagesModal(tpl resources.Template) *discordgo.InteractionResponseData {
+	return &discordgo.InteractionResponseData{
+		Title: "Set Broadcast Messages",
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.TextInput{
+					CustomID:    "broadcast-messages",
+					Label:       "Messages (one seconds: message per line)",
+					Placeholder: "300: Join our Discord",
+					Style:       discordgo.TextInputParagraph,
+					Value:       resources.FormatBroadcastMessages(tpl.BroadcastMessage),
+				},
+			}},
+		},
+	}
+}
+
+// mapsModal edits the map rotation, starting map and game mode of the overrides of a server. Templates edit them with
+// the map picker instead.
+func mapsModal(tpl resources.Template) *discordgo.InteractionResponseData {
+	var ids []string
+	for _, m := range tpl.MapRotation {
+		ids = append(ids, m.Id)
+	}
+	return &discordgo.InteractionResponseData{
+		Title: "Set Maps",
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.TextInput{
+					CustomID:    "map-rotation",
+					Label:       "Map rotation (one map ID per line)",
+					Placeholder: "e.g. foy_warfare",
+					Style:       discordgo.TextInputParagraph,
+					Value:       strings.Join(ids, "\n"),
+				},
+			}},
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.TextInput{
+					CustomID:    "starting-map",
+					Label:       "Starting map ID",
+					Placeholder: "Must be part of the rotation",
+					Style:       discordgo.TextInputShort,
+					Value:       tpl.StartingMap,
+				},
+			}},
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.TextInput{
+					CustomID:    "game-mode",
+					Label:       "Game mode",
+					Placeholder: "warfare, offensive or skirmish",
+					Style:       discordgo.TextInputShort,
+					Value:       string(tpl.GameMode),
+				},
+			}},
+		},
+	}
+}
+
+func updateBroadcastMessages(tpl *resources.Template, d broadcastMessagesData) (err error) {
+	tpl.BroadcastMessage, err = resources.ParseBroadcastMessages(d.Messages)
+	return err
+}
+
+// updateMaps returns the update of the map settings. The IDs of the rotation are resolved with the maps of the game.
+func (c *EmbedCommand) updateMaps(ctx context.Context) TemplateUpdate[mapsData] {
+	return func(tpl *resources.Template, d mapsData) error {
+		tpl.MapRotation = nil
+		if ids := strings.Fields(d.Rotation); len(ids) != 0 {
+			available, err := c.maps.Maps(ctx, c.servers)
+			if err != nil {
+				return fmt.Errorf("reading the maps of the game: %w", err)
+			}
+			if tpl.MapRotation, err = resources.ResolveMaps(ids, available); err != nil {
+				return err
+			}
+		}
+		tpl.StartingMap = strings.TrimSpace(d.StartingMap)
+		tpl.GameMode = crcon.GameMode(strings.ToLower(strings.TrimSpace(d.GameMode)))
+		return nil
+	}
+}
+
+// serverTemplate resolves the settings which are applied to the server: the settings of the template including the
+// inherited ones, replaced by the overrides of the server. sources maps the settings which are not set by the
+// template itself to where they come from.
+func serverTemplate(templates internal.Repository[resources.Template], server resources.Server, tpl resources.Template) (res resources.Template, sources map[string]string, err error) {
+	res, inherited, err := tpl.Effective(templates.Find)
+	if err != nil {
+		return res, nil, err
+	}
+	sources = map[string]string{}
+	for name, from := range inherited {
+		sources[name] = "inherited from " + from
+	}
+	if server.Overrides != nil {
+		var overridden []string
+		res, overridden = res.Override(*server.Overrides)
+		for _, name := range overridden {
+			sources[name] = sourceServer
+		}
+	}
+	return res, sources, nil
+}
+
+// changesPreview lists the settings which change when the template is applied, compared to the values applied last,
+// together with where each new value comes from.
+func changesPreview(next resources.Template, sources map[string]string, applied *resources.AppliedTemplate) string {
+	var previous resources.Template
+	if applied != nil {
+		previous = applied.Values
+	}
+	// the server name is shown in an own field
+	diffs := next.Differences(previous, resources.SettingServerName)
+	if len(diffs) == 0 {
+		return "No settings change"
+	}
+	var lines []string
+	for _, d := range diffs {
+		source, ok := sources[d.Setting]
+		if !ok {
+			source = "template"
+		}
+		if applied == nil {
+			lines = append(lines, fmt.Sprintf("**%s**: %s *(%s)*", d.Setting, previewValue(d.Expected), source))
+		} else {
+			lines = append(lines, fmt.Sprintf("**%s**: %s → %s *(%s)*", d.Setting, previewValue(d.Actual), previewValue(d.Expected), source))
+		}
+	}
+	return truncateField(strings.Join(lines, "\n"), 1024)
+}
+
+func previewValue(v string) string {
+	if v == "" {
+		return "*empty*"
+	}
+	return "`" + truncateField(strings.ReplaceAll(v, "\n", " | "), 60) + "`"
+}
+
+// overridesSummary names the settings the server overrides.
+func overridesSummary(s resources.Server) string {
+	if s.Overrides == nil || len(s.Overrides.Overrides()) == 0 {
+		return "none"
+	}
+	return strings.Join(s.Overrides.Overrides(), ", ")
+}
+
+func overrideButtons(s resources.Server) []discordgo.MessageComponent {
+	button := func(label, kind string) discordgo.Button {
+		return discordgo.Button{
+			Label:    label,
+			Style:    discordgo.SecondaryButton,
+			CustomID: customId(embedPrefix, "override", kind, s.ServerId),
+		}
+	}
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			button("Override Messages", "messages"),
+			button("Override Broadcast messages", "broadcast-messages"),
+			button("Override Thresholds", "thresholds"),
+			button("Override Server Settings", "server-settings"),
+			button("Override Profanity filter", "profanity-filter"),
+		}},
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			button("Override Maps", "maps"),
+			discordgo.Button{
+				Label:    "Clear Overrides",
+				Style:    discordgo.DangerButton,
+				Disabled: s.Overrides == nil,
+				CustomID: customId(embedPrefix, "clear-overrides", s.ServerId),
+			},
+		}},
+	}
+}
+
+func (c *EmbedCommand) onOverrideClick(s *discordgo.Session, i *discordgo.InteractionCreate, sid string) {
+	_, rest := peekId(i.MessageComponentData().CustomID)
+	kind, _ := peekId(rest)
+	md, ok := overrideModals[kind]
+	if !ok {
+		ErrorResponse(s, i.Interaction, "Unknown settings "+kind)
+		return
+	}
+	server, err := c.servers.Find(sid)
+	if err != nil {
+		c.logger.Error("find-server", "error", err)
+		storageErrorResponse(s, i.Interaction, "Error trying to find server with ID "+sid+".", err)
+		return
+	}
+	if server == nil {
+		ErrorResponse(s, i.Interaction, "Could not find server with ID "+sid+".")
+		return
+	}
+	var o resources.Template
+	if server.Overrides != nil {
+		o = *server.Overrides
+	}
+	m := md(o)
+	m.Title = "Override settings of this server"
+	m.CustomID = customId(embedPrefix, "confirm-override", kind, sid)
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: m,
+	})
+	if err != nil {
+		c.logger.Error("message-component-respond", "error", err)
+		ErrorResponse(s, i.Interaction, "Unknown error: "+err.Error())
+	}
+}
+
+func (c *EmbedCommand) onConfirmOverride(s *discordgo.Session, i *discordgo.InteractionCreate, sid string) {
+	_, rest := peekId(i.ModalSubmitData().CustomID)
+	kind, _ := peekId(rest)
+	switch kind {
+	case "messages":
+		confirmOverride(c, s, i, sid, updateMessages)
+	case "broadcast-messages":
+		confirmOverride(c, s, i, sid, updateBroadcastMessages)
+	case "thresholds":
+		confirmOverride(c, s, i, sid, updateThresholds)
+	case "server-settings":
+		confirmOverride(c, s, i, sid, updateServerSettings)
+	case "profanity-filter":
+		confirmOverride(c, s, i, sid, updateProfanityFilter)
+	case "maps":
+		ctx, cancel := operationContext(i.Interaction)
+		defer cancel()
+		confirmOverride(c, s, i, sid, c.updateMaps(ctx))
+	default:
+		ErrorResponse(s, i.Interaction, "Unknown settings "+kind)
+	}
+}
+
+// confirmOverride applies the data of a modal to the overrides of the server. Settings left empty in the modal are
+// taken from the template again.
+func confirmOverride[T any](c *EmbedCommand, s *discordgo.Session, i *discordgo.InteractionCreate, sid string, update TemplateUpdate[T]) {
+	server, err := c.servers.Find(sid)
+	if err != nil {
+		c.logger.Error("find-server", "error", err)
+		storageErrorResponse(s, i.Interaction, "Error trying to find server with ID "+sid+".", err)
+		return
+	}
+	if server == nil {
+		ErrorResponse(s, i.Interaction, "Could not find server with ID "+sid+".")
+		return
+	}
+	var d T
+	if err := marshaller.Unmarshal(i.ModalSubmitData().Components, &d); err != nil {
+		c.logger.Error("parse-data", "error", err)
+		ErrorResponse(s, i.Interaction, "Unknown error: "+err.Error())
+		return
+	}
+	var o resources.Template
+	if server.Overrides != nil {
+		o = *server.Overrides
+	}
+	if err := update(&o, d); err != nil {
+		ErrorResponse(s, i.Interaction, "The provided values are not valid. Error: "+err.Error())
+		return
+	}
+	// the overrides are a partial template without name
+	check := o
+	check.Name = server.Name
+	if err := check.Validate(); err != nil {
+		ErrorResponse(s, i.Interaction, "The provided values are not valid. Error: "+err.Error())
+		return
+	}
+	server.Overrides = &o
+	if len(o.Overrides()) == 0 {
+		server.Overrides = nil
+	}
+	c.saveAndRender(s, i, server)
+}
+
+func (c *EmbedCommand) onClearOverrides(s *discordgo.Session, i *discordgo.InteractionCreate, sid string) {
+	server, err := c.servers.Find(sid)
+	if err != nil {
+		c.logger.Error("find-server", "error", err)
+		storageErrorResponse(s, i.Interaction, "Error trying to find server with ID "+sid+".", err)
+		return
+	}
+	if server == nil {
+		ErrorResponse(s, i.Interaction, "Could not find server with ID "+sid+".")
+		return
+	}
+	server.Overrides = nil
+	c.saveAndRender(s, i, server)
+}
+
+// saveAndRender saves the server and updates the server embed the interaction originated from.
+func (c *EmbedCommand) saveAndRender(s *discordgo.Session, i *discordgo.InteractionCreate, server *resources.Server) {
+	if err := c.servers.Save(*server); err != nil {
+		c.logger.Error("save-server", "error", err)
+		storageErrorResponse(s, i.Interaction, "Error saving server.", err)
+		return
+	}
+	ctx, cancel := operationContext(i.Interaction)
+	defer cancel()
+	embeds, components, err := serverEmbed(ctx, c.templates, *server)
+	if err != nil {
+		c.logger.Error("create-message-embeds", "error", err)
+		ErrorResponse(s, i.Interaction, "The overrides were saved, but there was an error creating the message components. "+resilience.Describe(err))
+		return
+	}
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     embeds,
+			Components: components,
+		},
+	})
+	if err != nil {
+		c.logger.Error("edit-response", "error", err)
+	}
+}
@@ -0,0 +1,235 @@
+package commands
+
+import (
+	"context"
+	"github.com/bwmarrin/discordgo"
+	"github.com/floriansw/go-crcon"
+	"github.com/floriansw/hll-discord-server-watcher/internal/hosting"
+	"github.com/floriansw/hll-discord-server-watcher/internal/resilience"
+	"time"
+)
+
+const (
+	// backendTimeout limits a single request to CRCon or the hosting provider.
+	backendTimeout = 15 * time.Second
+	// operationTimeout limits all requests made for an interaction or background job, including retries.
+	operationTimeout = time.Minute
+	// interactionLifetime is the time an interaction token can be used to edit the response.
+	interactionLifetime = 15 * time.Minute
+)
+
+// breakers are shared by all commands and background jobs, so that requests to a failing server are stopped for
+// every interaction.
+var breakers = resilience.NewBreakers(3, time.Minute)
+
+// operationContext returns a context which is done after the operation timeout, or when the response of the
+// interaction can no longer be edited.
+func operationContext(i *discordgo.Interaction) (context.Context, context.CancelFunc) {
+	deadline := time.Now().Add(operationTimeout)
+	if created, err := discordgo.SnowflakeTimestamp(i.ID); err == nil && created.Add(interactionLifetime).Before(deadline) {
+		deadline = created.Add(interactionLifetime)
+	}
+	return context.WithDeadline(context.Background(), deadline)
+}
+
+// resilientCRCon retries reads and writes, which set values. Switching the map and changing the rotation are not
+// retried, as the server might have executed them even though the request failed.
+type resilientCRCon struct {
+	client  CRCon
+	breaker *resilience.Breaker
+}
+
+func (r *resilientCRCon) call(ctx context.Context, f func(ctx context.Context) error) error {
+	return r.breaker.Call(ctx, resilience.DefaultPolicy, f)
+}
+
+func (r *resilientCRCon) SetTeamSwitchCooldown(ctx context.Context, minutes int) error {
+	return r.call(ctx, func(ctx context.Context) error {
+		return r.client.SetTeamSwitchCooldown(ctx, minutes)
+	})
+}
+
+func (r *resilientCRCon) SetAutoBalanceThreshold(ctx context.Context, maxDiff int) error {
+	return r.call(ctx, func(ctx context.Context) error {
+		return r.client.SetAutoBalanceThreshold(ctx, maxDiff)
+	})
+}
+
+func (r *resilientCRCon) SetAutoBalanceEnabled(ctx context.Context, enabled bool) error {
+	return r.call(ctx, func(ctx context.Context) error {
+		return r.client.SetAutoBalanceEnabled(ctx, enabled)
+	})
+}
+
+func (r *resilientCRCon) SetIdleAutoKickTime(ctx context.Context, minutes int) error {
+	return r.call(ctx, func(ctx context.Context) error {
+		return r.client.SetIdleAutoKickTime(ctx, minutes)
+	})
+}
+
+func (r *resilientCRCon) SetMaxPingAutoKick(ctx context.Context, ms int) error {
+	return r.call(ctx, func(ctx context.Context) error {
+		return r.client.SetMaxPingAutoKick(ctx, ms)
+	})
+}
+
+func (r *resilientCRCon) SetQueueLength(ctx context.Context, length int) error {
+	return r.call(ctx, func(ctx context.Context) error {
+		return r.client.SetQueueLength(ctx, length)
+	})
+}
+
+func (r *resilientCRCon) SetVipSlots(ctx context.Context, slots int) error {
+	return r.call(ctx, func(ctx context.Context) error {
+		return r.client.SetVipSlots(ctx, slots)
+	})
+}
+
+func (r *resilientCRCon) SetVoteKickEnabled(ctx context.Context, enabled bool) error {
+	return r.call(ctx, func(ctx context.Context) error {
+		return r.client.SetVoteKickEnabled(ctx, enabled)
+	})
+}
+
+func (r *resilientCRCon) SwitchMap(ctx context.Context, id string) error {
+	return r.breaker.Call(ctx, resilience.NoRetry, func(ctx context.Context) error {
+		return r.client.SwitchMap(ctx, id)
+	})
+}
+
+func (r *resilientCRCon) SetMapRotation(ctx context.Context, ids []string) error {
+	return r.breaker.Call(ctx, resilience.NoRetry, func(ctx context.Context) error {
+		return r.client.SetMapRotation(ctx, ids)
+	})
+}
+
+func (r *resilientCRCon) Maps(ctx context.Context) (res []crcon.Map, err error) {
+	err = r.call(ctx, func(ctx context.Context) (err error) {
+		res, err = r.client.Maps(ctx)
+		return
+	})
+	return
+}
+
+func (r *resilientCRCon) MapRotation(ctx context.Context) (res crcon.MapRotation, err error) {
+	err = r.call(ctx, func(ctx context.Context) (err error) {
+		res, err = r.client.MapRotation(ctx)
+		return
+	})
+	return
+}
+
+func (r *resilientCRCon) AutoBroadcastConfig(ctx context.Context) (res crcon.AutoBroadcastConfig, err error) {
+	err = r.call(ctx, func(ctx context.Context) (err error) {
+		res, err = r.client.AutoBroadcastConfig(ctx)
+		return
+	})
+	return
+}
+
+func (r *resilientCRCon) Profanities(ctx context.Context) (res []string, err error) {
+	err = r.call(ctx, func(ctx context.Context) (err error) {
+		res, err = r.client.Profanities(ctx)
+		return
+	})
+	return
+}
+
+func (r *resilientCRCon) SetProfanities(ctx context.Context, prof []string) error {
+	return r.call(ctx, func(ctx context.Context) error {
+		return r.client.SetProfanities(ctx, prof)
+	})
+}
+
+func (r *resilientCRCon) SetAutoBroadcastConfig(ctx context.Context, config crcon.AutoBroadcastConfig) error {
+	return r.call(ctx, func(ctx context.Context) error {
+		return r.client.SetAutoBroadcastConfig(ctx, config)
+	})
+}
+
+func (r *resilientCRCon) SetWelcomeMessage(ctx context.Context, message string) error {
+	return r.call(ctx, func(ctx context.Context) error {
+		return r.client.SetWelcomeMessage(ctx, message)
+	})
+}
+
+func (r *resilientCRCon) WelcomeMessage(ctx context.Context) (res string, err error) {
+	err = r.call(ctx, func(ctx context.Context) (err error) {
+		res, err = r.client.WelcomeMessage(ctx)
+		return
+	})
+	return
+}
+
+func (r *resilientCRCon) ServerSettings(ctx context.Context) (res crcon.ServerSettings, err error) {
+	err = r.call(ctx, func(ctx context.Context) (err error) {
+		res, err = r.client.ServerSettings(ctx)
+		return
+	})
+	return
+}
+
+func (r *resilientCRCon) PlayerIds(ctx context.Context) (res []string, err error) {
+	err = r.call(ctx, func(ctx context.Context) (err error) {
+		res, err = r.client.PlayerIds(ctx)
+		return
+	})
+	return
+}
+
+func (r *resilientCRCon) GameState(ctx context.Context) (res crcon.GameState, err error) {
+	err = r.call(ctx, func(ctx context.Context) (err error) {
+		res, err = r.client.GameState(ctx)
+		return
+	})
+	return
+}
+
+func (r *resilientCRCon) OwnPermissions(ctx context.Context) (res crcon.OwnPermissions, err error) {
+	err = r.call(ctx, func(ctx context.Context) (err error) {
+		res, err = r.client.OwnPermissions(ctx)
+		return
+	})
+	return
+}
+
+// resilientProvider retries reads and writes, which set values. Power actions are not retried, as a restart might
+// have been executed even though the request failed.
+type resilientProvider struct {
+	provider hosting.Provider
+	breaker  *resilience.Breaker
+}
+
+func (r *resilientProvider) ServerInfo(ctx context.Context) (res *hosting.ServerInfo, err error) {
+	err = r.breaker.Call(ctx, resilience.DefaultPolicy, func(ctx context.Context) (err error) {
+		res, err = r.provider.ServerInfo(ctx)
+		return
+	})
+	return
+}
+
+func (r *resilientProvider) SetServerInfo(ctx context.Context, name, password string) error {
+	return r.breaker.Call(ctx, resilience.DefaultPolicy, func(ctx context.Context) error {
+		return r.provider.SetServerInfo(ctx, name, password)
+	})
+}
+
+func (r *resilientProvider) Start(ctx context.Context) error {
+	return r.breaker.Call(ctx, resilience.NoRetry, r.provider.Start)
+}
+
+func (r *resilientProvider) Stop(ctx context.Context) error {
+	return r.breaker.Call(ctx, resilience.NoRetry, r.provider.Stop)
+}
+
+func (r *resilientProvider) Restart(ctx context.Context) error {
+	return r.breaker.Call(ctx, resilience.NoRetry, r.provider.Restart)
+}
+
+func (r *resilientProvider) Status(ctx context.Context) (res hosting.Status, err error) {
+	err = r.breaker.Call(ctx, resilience.DefaultPolicy, func(ctx context.Context) (err error) {
+		res, err = r.provider.Status(ctx)
+		return
+	})
+	return
+}
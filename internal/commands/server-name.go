@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"github.com/floriansw/hll-discord-server-watcher/internal/hosting"
+	"github.com/floriansw/hll-discord-server-watcher/resources"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// nameOnApply returns the name the server gets when the template is applied: the name set explicitly in the pending
+// update, otherwise the server name template rendered for the server. It is empty when the name is not changed.
+func nameOnApply(server resources.Server, event, nameTemplate string, at time.Time) (string, error) {
+	if server.PendingUpdate != nil && server.PendingUpdate.ServerName != "" {
+		return server.PendingUpdate.ServerName, nil
+	}
+	if nameTemplate == "" {
+		return "", nil
+	}
+	name, missing := resources.RenderServerName(nameTemplate, server.ServerNameValues(event, at))
+	if len(missing) != 0 {
+		return "", fmt.Errorf("the server name template uses the unknown placeholders %s, set them as name template variables of the server", strings.Join(missing, ", "))
+	}
+	if err := resources.ValidateServerName(name); err != nil {
+		return "", fmt.Errorf("the rendered server name %q is not valid: %w", name, err)
+	}
+	return name, nil
+}
+
+// serverNamePreview renders the server name template of the template embed for the first server using it, or for an
+// example server. Placeholders of custom variables are kept when the server does not set them.
+func serverNamePreview(tpl resources.Template, usedBy []resources.Server) string {
+	if tpl.ServerNameTemplate == "" {
+		return "not set"
+	}
+	server := resources.Server{Name: "Example server"}
+	if len(usedBy) != 0 {
+		server = usedBy[0]
+	}
+	name, missing := resources.RenderServerName(tpl.ServerNameTemplate, server.ServerNameValues(tpl.Name, time.Now()))
+	res := fmt.Sprintf("`%s`\n*for %s, %d/%d characters*", truncateField(name, 200), server.Name, utf8.RuneCountInString(name), resources.MaxServerNameLength)
+	if len(missing) != 0 {
+		res += "\n⚠️ Servers need to set the variables " + strings.Join(missing, ", ")
+	} else if err := resources.ValidateServerName(name); err != nil {
+		res += "\n⚠️ " + err.Error()
+	}
+	return res
+}
+
+// updateServerInfo sets the name and password of the server at the hosting provider, empty values are left unchanged.
+// The server info is only written when it differs from the current one, changed then tells that the server needs to
+// be restarted. current is the name of the server afterwards.
+func updateServerInfo(ctx context.Context, hc hosting.Provider, name, password string) (current string, changed bool, err error) {
+	si, err := hc.ServerInfo(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	next := *si
+	if name != "" {
+		next.Name = name
+	}
+	if password != "" {
+		next.Password = password
+	}
+	if next == *si {
+		return si.Name, false, nil
+	}
+	if err := hc.SetServerInfo(ctx, next.Name, next.Password); err != nil {
+		return si.Name, false, err
+	}
+	return next.Name, true, nil
+}
@@ -0,0 +1,144 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"github.com/floriansw/go-discordgo-utils/marshaller"
+	. "github.com/floriansw/go-discordgo-utils/util"
+	"github.com/floriansw/hll-discord-server-watcher/internal"
+	"github.com/floriansw/hll-discord-server-watcher/resources"
+	"log/slog"
+)
+
+type exportTemplateData struct {
+	TemplateId string `discordgo:"template"`
+}
+
+type ExportTemplateCommand struct {
+	logger    *slog.Logger
+	config    *internal.Config
+	templates internal.Repository[resources.Template]
+}
+
+func NewExportTemplateCommand(l *slog.Logger, c *internal.Config, m internal.Repository[resources.Template]) *ExportTemplateCommand {
+	return &ExportTemplateCommand{
+		logger:    l,
+		config:    c,
+		templates: m,
+	}
+}
+
+func (c *ExportTemplateCommand) Definition(cmd string) *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        cmd,
+		Description: "Exports a template (or all templates) as a JSON file",
+		Options: []*discordgo.ApplicationCommandOption{{
+			Name:         "template",
+			Description:  "The ID of the template to export. Leave empty to export all templates",
+			Type:         discordgo.ApplicationCommandOptionString,
+			Required:     false,
+			Autocomplete: true,
+		}},
+	}
+}
+
+func (c *ExportTemplateCommand) OnAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	l, err := c.templates.Search(focusedValue(i))
+	if err != nil {
+		c.logger.Error("list-templates", "error", err)
+		storageErrorResponse(s, i.Interaction, "Could not list templates.", err)
+		return
+	}
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: autocompleteChoices(l),
+		},
+	})
+	if err != nil {
+		c.logger.Error("response", "error", err)
+		ErrorResponse(s, i.Interaction, "Could not send response. Error: "+err.Error())
+		return
+	}
+}
+
+func (c *ExportTemplateCommand) OnCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	var d exportTemplateData
+	if err := marshaller.Unmarshal(i.Interaction.ApplicationCommandData().Options, &d); err != nil {
+		c.logger.Error("load-export-tpl-data", "error", err)
+		ErrorResponse(s, i.Interaction, "Could not load data from interaction. Error: "+err.Error())
+		return
+	}
+
+	var (
+		content  []byte
+		fileName string
+		message  string
+	)
+	if d.TemplateId != "" {
+		tpl, err := c.templates.Find(d.TemplateId)
+		if err != nil {
+			c.logger.Error("find-template", "error", err)
+			storageErrorResponse(s, i.Interaction, "There was an error fetching template details.", err)
+			return
+		}
+		if tpl == nil {
+			ErrorResponse(s, i.Interaction, "Could not find template with ID "+d.TemplateId)
+			return
+		}
+		if content, err = json.MarshalIndent(tpl, "", "  "); err != nil {
+			c.logger.Error("marshal-templates", "error", err)
+			ErrorResponse(s, i.Interaction, "Could not create the export file. Error: "+err.Error())
+			return
+		}
+		fileName = fmt.Sprintf("template-%s.json", tpl.TemplateId)
+		message = fmt.Sprintf("Export of the template **%s**.", tpl.Name)
+	} else {
+		l, err := c.templates.List()
+		if err != nil {
+			c.logger.Error("list-templates", "error", err)
+			storageErrorResponse(s, i.Interaction, "Could not list templates.", err)
+			return
+		}
+		var templates []resources.Template
+		for _, tid := range l {
+			tpl, err := c.templates.Find(tid)
+			if err != nil {
+				c.logger.Error("find-template", "error", err)
+				storageErrorResponse(s, i.Interaction, "Could not find template with ID "+tid+".", err)
+				return
+			}
+			if tpl != nil {
+				templates = append(templates, *tpl)
+			}
+		}
+		if content, err = resources.ExportTemplates(templates); err != nil {
+			c.logger.Error("marshal-templates", "error", err)
+			ErrorResponse(s, i.Interaction, "Could not create the export file. Error: "+err.Error())
+			return
+		}
+		fileName = "templates.json"
+		message = fmt.Sprintf("Export of all %d templates.", len(templates))
+	}
+
+	_, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &message,
+		Files: []*discordgo.File{{
+			Name:        fileName,
+			ContentType: "application/json",
+			Reader:      bytes.NewReader(content),
+		}},
+	})
+	if err != nil {
+		c.logger.Error("edit-response", "error", err)
+		return
+	}
+}
@@ -0,0 +1,149 @@
+package commands
+
+import (
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	. "github.com/floriansw/go-discordgo-utils/util"
+	"github.com/floriansw/hll-discord-server-watcher/internal"
+	"github.com/floriansw/hll-discord-server-watcher/resources"
+	"github.com/google/uuid"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+const (
+	importConflictCopy      = "copy"
+	importConflictOverwrite = "overwrite"
+
+	maxImportFileSize = 1024 * 1024
+)
+
+type ImportTemplateCommand struct {
+	logger    *slog.Logger
+	config    *internal.Config
+	templates internal.Repository[resources.Template]
+}
+
+func NewImportTemplateCommand(l *slog.Logger, c *internal.Config, m internal.Repository[resources.Template]) *ImportTemplateCommand {
+	return &ImportTemplateCommand{
+		logger:    l,
+		config:    c,
+		templates: m,
+	}
+}
+
+func (c *ImportTemplateCommand) Definition(cmd string) *discordgo.ApplicationCommand {
+	return &discordgo.ApplicationCommand{
+		Name:        cmd,
+		Description: "Imports one or more templates from a JSON file created with the template export",
+		Options: []*discordgo.ApplicationCommandOption{{
+			Name:        "file",
+			Description: "The JSON file containing the template(s)",
+			Type:        discordgo.ApplicationCommandOptionAttachment,
+			Required:    true,
+		}, {
+			Name:        "conflict",
+			Description: "What to do when a template with the same ID already exists (default: create a copy)",
+			Type:        discordgo.ApplicationCommandOptionString,
+			Required:    false,
+			Choices: []*discordgo.ApplicationCommandOptionChoice{{
+				Name:  "Create a copy",
+				Value: importConflictCopy,
+			}, {
+				Name:  "Overwrite the existing template",
+				Value: importConflictOverwrite,
+			}},
+		}},
+	}
+}
+
+func (c *ImportTemplateCommand) OnCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	data := i.Interaction.ApplicationCommandData()
+	conflict := importConflictCopy
+	var attachment *discordgo.MessageAttachment
+	for _, option := range data.Options {
+		switch option.Name {
+		case "file":
+			if data.Resolved != nil {
+				attachment = data.Resolved.Attachments[option.StringValue()]
+			}
+		case "conflict":
+			conflict = option.StringValue()
+		}
+	}
+	if attachment == nil {
+		ErrorResponse(s, i.Interaction, "Could not load the attached file from the interaction.")
+		return
+	}
+	if attachment.Size > maxImportFileSize {
+		ErrorResponse(s, i.Interaction, fmt.Sprintf("The attached file is too large, it must not exceed %d bytes.", maxImportFileSize))
+		return
+	}
+
+	b, err := downloadAttachment(attachment.URL)
+	if err != nil {
+		c.logger.Error("download-attachment", "error", err)
+		ErrorResponse(s, i.Interaction, "Could not download the attached file. Error: "+err.Error())
+		return
+	}
+	templates, err := resources.ParseTemplates(b)
+	if err != nil {
+		ErrorResponse(s, i.Interaction, "The attached file does not contain valid templates. Error: "+err.Error())
+		return
+	}
+
+	var result []string
+	for _, tpl := range templates {
+		if tpl.TemplateId == "" {
+			tpl.TemplateId = uuid.NewString()
+		}
+		existing, err := c.templates.Find(tpl.TemplateId)
+		if err != nil {
+			c.logger.Error("find-template", "error", err)
+			storageErrorResponse(s, i.Interaction, "There was an error fetching template details.", err)
+			return
+		}
+		action := "created"
+		if existing != nil && conflict == importConflictOverwrite {
+			action = "overwritten"
+		} else if existing != nil {
+			tpl.TemplateId = uuid.NewString()
+			tpl.Name = tpl.Name + " (copy)"
+			action = "created as copy"
+		}
+		if err := c.templates.Save(tpl); err != nil {
+			c.logger.Error("save-tpl", "error", err)
+			storageErrorResponse(s, i.Interaction, "There was an error saving the template "+tpl.Name+".", err)
+			return
+		}
+		result = append(result, fmt.Sprintf("* **%s** (ID %s): %s", tpl.Name, tpl.TemplateId, action))
+	}
+
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: String(fmt.Sprintf("Imported %d template(s):\n%s", len(templates), strings.Join(result, "\n"))),
+	})
+	if err != nil {
+		c.logger.Error("edit-response", "error", err)
+		return
+	}
+}
+
+func downloadAttachment(u string) ([]byte, error) {
+	res, err := http.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", res.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(res.Body, maxImportFileSize))
+}
@@ -0,0 +1,355 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/bwmarrin/discordgo"
+	"github.com/floriansw/go-crcon"
+	. "github.com/floriansw/go-discordgo-utils/util"
+	"github.com/floriansw/hll-discord-server-watcher/internal"
+	"github.com/floriansw/hll-discord-server-watcher/internal/resilience"
+	"github.com/floriansw/hll-discord-server-watcher/resources"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// mapsCacheDuration is the time the maps of the game are cached for the map picker, so that selecting filters does
+// not request them again.
+const mapsCacheDuration = 10 * time.Minute
+
+// filterAll is the filter value of the map picker which matches all game modes or environments.
+const filterAll = "all"
+
+var errNoMapSource = errors.New("no server with CRCon or RCON credentials is set up to read the maps from")
+
+// mapCatalog caches the maps the game servers can play.
+type mapCatalog struct {
+	mu        sync.Mutex
+	maps      []resources.Map
+	fetchedAt time.Time
+}
+
+// Maps returns the maps of the first server which can be managed. All servers run the same game, so the maps of one
+// server are valid for all of them.
+func (c *mapCatalog) Maps(ctx context.Context, servers internal.Repository[resources.Server]) ([]resources.Map, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.maps != nil && time.Since(c.fetchedAt) < mapsCacheDuration {
+		return c.maps, nil
+	}
+	server, err := mapSource(servers)
+	if err != nil {
+		return nil, err
+	}
+	maps, err := crconClient(*server).Maps(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.maps = nil
+	for _, m := range maps {
+		c.maps = append(c.maps, fromCRConMap(m))
+	}
+	slices.SortStableFunc(c.maps, func(a, b resources.Map) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	c.fetchedAt = time.Now()
+	return c.maps, nil
+}
+
+func mapSource(servers internal.Repository[resources.Server]) (*resources.Server, error) {
+	summaries, err := servers.ListSummaries()
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, s := range summaries {
+		if s.Manageable {
+			ids = append(ids, s.Id)
+		}
+	}
+	slices.Sort(ids)
+	for _, id := range ids {
+		s, err := servers.Find(id)
+		if err != nil {
+			return nil, err
+		}
+		if s != nil && s.RConConfigured() {
+			return s, nil
+		}
+	}
+	return nil, errNoMapSource
+}
+
+func fromCRConMap(m crcon.Map) resources.Map {
+	return resources.Map{Id: m.Id, Name: m.Name, GameMode: m.GameMode, Environment: m.Environment}
+}
+
+// mapPickerState is the filter of the map picker, it is kept in the custom IDs of its components.
+type mapPickerState struct {
+	mode        string
+	environment string
+	templateId  string
+}
+
+func parseMapPickerState(cid string) mapPickerState {
+	p := strings.Split(cid, "#")
+	if len(p) < 5 {
+		return mapPickerState{mode: filterAll, environment: filterAll, templateId: p[len(p)-1]}
+	}
+	return mapPickerState{mode: p[len(p)-3], environment: p[len(p)-2], templateId: p[len(p)-1]}
+}
+
+func (s mapPickerState) id(action string) string {
+	return customId(templatesPrefix, action, s.mode, s.environment, s.templateId)
+}
+
+func (s mapPickerState) matches(m resources.Map) bool {
+	return (s.mode == filterAll || m.GameMode == s.mode) && (s.environment == filterAll || m.Environment == s.environment)
+}
+
+func mapPicker(tpl resources.Template, maps []resources.Map, state mapPickerState) (string, []discordgo.MessageComponent) {
+	var b strings.Builder
+	b.WriteString("**Map rotation of " + tpl.Name + "**\n")
+	b.WriteString(mapRotationSummary(tpl, 1500))
+	b.WriteString("\n\nFilter the maps by game mode and environment, then select the maps to add them to the end of the rotation.")
+
+	modes := []discordgo.SelectMenuOption{{Label: "All game modes", Value: filterAll}}
+	for _, m := range resources.GameModes {
+		modes = append(modes, discordgo.SelectMenuOption{Label: gameModeName(m), Value: string(m)})
+	}
+	environments := []discordgo.SelectMenuOption{{Label: "All environments", Value: filterAll}}
+	for _, m := range maps {
+		if len(environments) < 25 && !slices.ContainsFunc(environments, func(o discordgo.SelectMenuOption) bool { return o.Value == m.Environment }) {
+			environments = append(environments, discordgo.SelectMenuOption{Label: m.Environment, Value: m.Environment})
+		}
+	}
+	for idx := range modes {
+		modes[idx].Default = modes[idx].Value == state.mode
+	}
+	for idx := range environments {
+		environments[idx].Default = environments[idx].Value == state.environment
+	}
+
+	var available []discordgo.SelectMenuOption
+	for _, m := range maps {
+		if !state.matches(m) {
+			continue
+		}
+		if len(available) == 25 {
+			b.WriteString(" More than 25 maps match the filter, narrow it down to see all of them.")
+			break
+		}
+		available = append(available, discordgo.SelectMenuOption{Label: m.Name, Value: m.Id, Description: m.GameMode + ", " + m.Environment})
+	}
+	add := discordgo.SelectMenu{
+		MenuType:    discordgo.StringSelectMenu,
+		CustomID:    state.id("maps-add"),
+		Placeholder: "Add maps to the rotation",
+		MinValues:   Int(1),
+		MaxValues:   len(available),
+		Options:     available,
+	}
+	if len(available) == 0 {
+		add.Placeholder = "No maps match the filter"
+		add.MaxValues = 1
+		add.Options = []discordgo.SelectMenuOption{{Label: "No maps", Value: "none"}}
+		add.Disabled = true
+	}
+
+	starting := []discordgo.SelectMenuOption{{Label: "Keep the current map", Value: "none", Default: tpl.StartingMap == ""}}
+	for _, m := range tpl.MapRotation {
+		if len(starting) == 25 {
+			break
+		}
+		if slices.ContainsFunc(starting, func(o discordgo.SelectMenuOption) bool { return o.Value == m.Id }) {
+			continue
+		}
+		starting = append(starting, discordgo.SelectMenuOption{Label: "Start with " + m.Name, Value: m.Id, Description: m.GameMode + ", " + m.Environment, Default: m.Id == tpl.StartingMap})
+	}
+
+	return b.String(), []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.SelectMenu{MenuType: discordgo.StringSelectMenu, CustomID: state.id("maps-mode"), Options: modes},
+		}},
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.SelectMenu{MenuType: discordgo.StringSelectMenu, CustomID: state.id("maps-environment"), Options: environments},
+		}},
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{add}},
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.SelectMenu{MenuType: discordgo.StringSelectMenu, CustomID: state.id("maps-start"), Options: starting},
+		}},
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    "Remove last map",
+				CustomID: state.id("maps-remove-last"),
+				Style:    discordgo.SecondaryButton,
+				Disabled: len(tpl.MapRotation) == 0,
+			},
+			discordgo.Button{
+				Label:    "Clear rotation",
+				CustomID: state.id("maps-clear"),
+				Style:    discordgo.DangerButton,
+				Disabled: len(tpl.MapRotation) == 0,
+			},
+		}},
+	}
+}
+
+// mapRotationSummary lists the maps of the rotation, cut to the given length.
+func mapRotationSummary(tpl resources.Template, limit int) string {
+	if len(tpl.MapRotation) == 0 && tpl.GameMode != "" {
+		return fmt.Sprintf("All %s maps of the server.", tpl.GameMode)
+	}
+	if len(tpl.MapRotation) == 0 {
+		return "No map rotation set, the rotation of the server is kept."
+	}
+	var b strings.Builder
+	for idx, m := range tpl.MapRotation {
+		line := fmt.Sprintf("%d. %s\n", idx+1, m)
+		if tpl.GameMode != "" && m.GameMode != string(tpl.GameMode) {
+			line = fmt.Sprintf("%d. ~~%s~~ (skipped, not %s)\n", idx+1, m, tpl.GameMode)
+		}
+		if b.Len()+len(line) > limit {
+			b.WriteString(fmt.Sprintf("… and %d more\n", len(tpl.MapRotation)-idx))
+			break
+		}
+		b.WriteString(line)
+	}
+	starting := "keep the current map"
+	if idx := slices.IndexFunc(tpl.MapRotation, func(m resources.Map) bool { return m.Id == tpl.StartingMap }); idx != -1 {
+		starting = tpl.MapRotation[idx].String()
+	}
+	b.WriteString("Starting map: " + starting)
+	return b.String()
+}
+
+// onMapPicker renders the map picker into the response of the interaction. update changes the template according to
+// the interaction, it returns false when the template was not changed.
+func (c *TemplatesCommand) onMapPicker(s *discordgo.Session, i *discordgo.InteractionCreate, state mapPickerState, update func(tpl *resources.Template, maps []resources.Map) bool) {
+	tpl, err := c.templates.Find(state.templateId)
+	if err != nil {
+		c.logger.Error("find-template", "error", err)
+		storageErrorResponse(s, i.Interaction, "There was an error fetching template details.", err)
+		return
+	}
+	if tpl == nil {
+		ErrorResponse(s, i.Interaction, "Could not find template with ID "+state.templateId)
+		return
+	}
+	ctx, cancel := operationContext(i.Interaction)
+	defer cancel()
+	maps, err := c.maps.Maps(ctx, c.servers)
+	if err != nil {
+		c.logger.Error("list-maps", "error", err)
+		ErrorResponse(s, i.Interaction, "Could not load the maps of the game. "+resilience.Describe(err))
+		return
+	}
+	if update(tpl, maps) {
+		if err := tpl.Validate(); err != nil {
+			ErrorResponse(s, i.Interaction, "The map rotation is not valid. Error: "+err.Error())
+			return
+		}
+		tpl.Version++
+		if err := c.templates.Save(*tpl); err != nil {
+			c.logger.Error("save-template", "error", err)
+			storageErrorResponse(s, i.Interaction, "There was an error saving the template.", err)
+			return
+		}
+	}
+	content, components := mapPicker(*tpl, maps, state)
+	users, err := templateUsers(c.templates, c.servers, tpl.TemplateId)
+	if err != nil {
+		c.logger.Error("list-template-users", "error", err)
+	}
+	if w := usageWarning(users); w != "" {
+		content += "\n" + w
+	}
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content:    &content,
+		Components: &components,
+	})
+	if err != nil {
+		c.logger.Error("edit-response", "error", err)
+	}
+}
+
+func unchanged(*resources.Template, []resources.Map) bool {
+	return false
+}
+
+// onMapPickerOpen sends the map picker as an own message, further interactions update it instead of the template
+// embed.
+func (c *TemplatesCommand) onMapPickerOpen(s *discordgo.Session, i *discordgo.InteractionCreate, tplId string) {
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	state := mapPickerState{mode: filterAll, environment: filterAll, templateId: tplId}
+	if tpl, err := c.templates.Find(tplId); err == nil && tpl != nil && tpl.GameMode != "" {
+		state.mode = string(tpl.GameMode)
+	}
+	c.onMapPicker(s, i, state, unchanged)
+}
+
+func (c *TemplatesCommand) onMapPickerComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	id := i.MessageComponentData().CustomID
+	state := parseMapPickerState(id)
+	values := i.MessageComponentData().Values
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	switch {
+	case matchesId(id, customId(templatesPrefix, "maps-mode")):
+		state.mode = values[0]
+		c.onMapPicker(s, i, state, unchanged)
+	case matchesId(id, customId(templatesPrefix, "maps-environment")):
+		state.environment = values[0]
+		c.onMapPicker(s, i, state, unchanged)
+	case matchesId(id, customId(templatesPrefix, "maps-add")):
+		c.onMapPicker(s, i, state, func(tpl *resources.Template, maps []resources.Map) bool {
+			for _, v := range values {
+				idx := slices.IndexFunc(maps, func(m resources.Map) bool { return m.Id == v })
+				if idx != -1 {
+					tpl.MapRotation = append(tpl.MapRotation, maps[idx])
+				}
+			}
+			return true
+		})
+	case matchesId(id, customId(templatesPrefix, "maps-start")):
+		c.onMapPicker(s, i, state, func(tpl *resources.Template, _ []resources.Map) bool {
+			tpl.StartingMap = ""
+			if values[0] != "none" {
+				tpl.StartingMap = values[0]
+			}
+			return true
+		})
+	case matchesId(id, customId(templatesPrefix, "maps-remove-last")):
+		c.onMapPicker(s, i, state, func(tpl *resources.Template, _ []resources.Map) bool {
+			if len(tpl.MapRotation) != 0 {
+				tpl.MapRotation = tpl.MapRotation[:len(tpl.MapRotation)-1]
+			}
+			removeStartingMapOutsideRotation(tpl)
+			return true
+		})
+	case matchesId(id, customId(templatesPrefix, "maps-clear")):
+		c.onMapPicker(s, i, state, func(tpl *resources.Template, _ []resources.Map) bool {
+			tpl.MapRotation = nil
+			tpl.StartingMap = ""
+			return true
+		})
+	}
+}
+
+// removeStartingMapOutsideRotation unsets the starting map when it is no longer part of the rotation, e.g. after the
+// game mode changed.
+func removeStartingMapOutsideRotation(tpl *resources.Template) {
+	rotation, _ := tpl.Rotation(nil)
+	if !slices.ContainsFunc(rotation, func(m resources.Map) bool { return m.Id == tpl.StartingMap }) {
+		tpl.StartingMap = ""
+	}
+}
@@ -1,12 +1,15 @@
 package commands
 
 import (
+	"fmt"
 	"github.com/bwmarrin/discordgo"
+	"github.com/floriansw/go-crcon"
 	"github.com/floriansw/go-discordgo-utils/marshaller"
 	. "github.com/floriansw/go-discordgo-utils/util"
 	"github.com/floriansw/hll-discord-server-watcher/internal"
 	"github.com/floriansw/hll-discord-server-watcher/resources"
 	"log/slog"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -33,35 +36,74 @@ func (p profanityData) ProfanityFilter() []string {
 type thresholdsData struct {
 	TeamSwitchCooldown   string `discordgo:"team-switch-cooldown"`
 	AutoBalanceThreshold string `discordgo:"auto-balance-threshold"`
+	AutoBalanceEnabled   string `discordgo:"auto-balance-enabled"`
+	VoteKickEnabled      string `discordgo:"vote-kick-enabled"`
 }
 
-func (t thresholdsData) teamSwitchCooldown() int {
-	if v, err := strconv.Atoi(t.TeamSwitchCooldown); err != nil {
-		return 0
-	} else {
-		return v
+type serverSettingsData struct {
+	IdleAutoKickTime string `discordgo:"idle-auto-kick-time"`
+	MaxPingAutoKick  string `discordgo:"max-ping-auto-kick"`
+	QueueLength      string `discordgo:"queue-length"`
+	VipSlots         string `discordgo:"vip-slots"`
+}
+
+// parseOptionalInt parses a number of a modal, an empty value unsets the setting.
+func parseOptionalInt(name, v string) (*int, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return nil, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a number, got %q", name, v)
+	}
+	return &i, nil
+}
+
+// parseOptionalBool parses an on/off switch of a modal, an empty value unsets the setting.
+func parseOptionalBool(name, v string) (*bool, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "":
+		return nil, nil
+	case "on", "yes", "true":
+		return Bool(true), nil
+	case "off", "no", "false":
+		return Bool(false), nil
+	}
+	return nil, fmt.Errorf("%s must be on or off, got %q", name, v)
+}
+
+func optionalIntString(v *int) string {
+	if v == nil {
+		return ""
 	}
+	return strconv.Itoa(*v)
 }
 
-func (t thresholdsData) autoBalanceThreshold() int {
-	if v, err := strconv.Atoi(t.AutoBalanceThreshold); err != nil {
-		return 0
-	} else {
-		return v
+func optionalBoolString(v *bool) string {
+	if v == nil {
+		return ""
+	}
+	if *v {
+		return "on"
 	}
+	return "off"
 }
 
 type TemplatesCommand struct {
 	logger    *slog.Logger
 	config    *internal.Config
-	templates internal.Storage[resources.Template]
+	templates internal.Repository[resources.Template]
+	servers   internal.Repository[resources.Server]
+	maps      mapCatalog
 }
 
-func NewTemplatesCommand(l *slog.Logger, c *internal.Config, m internal.Storage[resources.Template]) *TemplatesCommand {
+func NewTemplatesCommand(l *slog.Logger, c *internal.Config, m internal.Repository[resources.Template], servers internal.Repository[resources.Server]) *TemplatesCommand {
 	return &TemplatesCommand{
 		logger:    l,
 		config:    c,
 		templates: m,
+		servers:   servers,
 	}
 }
 
@@ -80,29 +122,16 @@ func (c *TemplatesCommand) Definition(cmd string) *discordgo.ApplicationCommand
 }
 
 func (c *TemplatesCommand) OnAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	l, err := c.templates.List()
+	l, err := c.templates.Search(focusedValue(i))
 	if err != nil {
 		c.logger.Error("list-templates", "error", err)
-		ErrorResponse(s, i.Interaction, "Could not list templates. Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "Could not list templates.", err)
 		return
 	}
-	var choices []*discordgo.ApplicationCommandOptionChoice
-	for _, tid := range l {
-		tpl, err := c.templates.Find(tid)
-		if err != nil {
-			c.logger.Error("find-templates", "error", err)
-			ErrorResponse(s, i.Interaction, "Could not find template with ID "+tid+". Error: "+err.Error())
-			continue
-		}
-		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
-			Name:  tpl.Name,
-			Value: tpl.TemplateId,
-		})
-	}
 	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
 		Data: &discordgo.InteractionResponseData{
-			Choices: choices,
+			Choices: autocompleteChoices(l),
 		},
 	})
 	if err != nil {
@@ -129,10 +158,14 @@ func (c *TemplatesCommand) OnCommand(s *discordgo.Session, i *discordgo.Interact
 	template, err := c.templates.Find(d.Id)
 	if err != nil {
 		c.logger.Error("find-template", "error", err)
-		ErrorResponse(s, i.Interaction, "There was an error fetching template details. Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "There was an error fetching template details.", err)
+		return
+	}
+	if template == nil {
+		ErrorResponse(s, i.Interaction, "Could not find template with ID "+d.Id)
 		return
 	}
-	embeds, components := templateEmbed(template)
+	embeds, components := renderTemplate(c.logger, c.templates, c.servers, template)
 	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
 		Embeds:     &embeds,
 		Components: &components,
@@ -150,32 +183,196 @@ func valOrNotSet(v string) string {
 	return v
 }
 
-func templateEmbed(s *resources.Template) (embeds []*discordgo.MessageEmbed, components []discordgo.MessageComponent) {
+// templateUsers returns the servers running the template or a template inheriting from it, sorted by their name.
+func templateUsers(templates internal.Repository[resources.Template], servers internal.Repository[resources.Server], tplId string) (res []resources.Server, err error) {
+	tpls, err := templates.ListSummaries()
+	if err != nil {
+		return nil, err
+	}
+	used := resources.Descendants(tpls, tplId)
+	used[tplId] = true
+	summaries, err := servers.ListSummaries()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range summaries {
+		if !used[s.TemplateId] {
+			continue
+		}
+		server, err := servers.Find(s.Id)
+		if err != nil {
+			return nil, err
+		}
+		if server != nil {
+			res = append(res, *server)
+		}
+	}
+	slices.SortFunc(res, func(a, b resources.Server) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return
+}
+
+// templateChildren returns the templates inheriting directly from the template.
+func templateChildren(templates internal.Repository[resources.Template], tplId string) (res []resources.Template, err error) {
+	summaries, err := templates.ListSummaries()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range summaries {
+		if s.ParentId != tplId {
+			continue
+		}
+		tpl, err := templates.Find(s.Id)
+		if err != nil {
+			return nil, err
+		}
+		if tpl != nil {
+			res = append(res, *tpl)
+		}
+	}
+	return
+}
+
+// usageWarning tells that changes of a template only take effect on the servers using it when the template is
+// applied to them again. It is empty when no server uses the template.
+func usageWarning(users []resources.Server) string {
+	if len(users) == 0 {
+		return ""
+	}
+	return "⚠️ This template is used by " + usedNames(users) + ". Changes only take effect on these servers when the template is applied to them again."
+}
+
+func usedNames(users []resources.Server) string {
+	var names []string
+	for _, u := range users {
+		names = append(names, "**"+u.Name+"**")
+	}
+	return strings.Join(names, ", ")
+}
+
+// templateView holds what the template embed shows besides the template itself.
+type templateView struct {
+	// effective are the settings including the inherited ones, inherited maps the names of inherited settings to the
+	// template they are inherited from.
+	effective resources.Template
+	inherited map[string]string
+	// resolveErr is set when the parent templates can not be resolved, the own settings are shown then.
+	resolveErr error
+	usedBy     []resources.Server
+	// parents are the templates which can be selected as parent.
+	parents []resources.Summary
+}
+
+// renderTemplate creates the embed of the template including inherited settings and the servers using it. The embed
+// is still created when the servers or templates can not be listed.
+func renderTemplate(logger *slog.Logger, templates internal.Repository[resources.Template], servers internal.Repository[resources.Server], tpl *resources.Template) ([]*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	v := templateView{}
+	v.effective, v.inherited, v.resolveErr = tpl.Effective(templates.Find)
+	var err error
+	if v.usedBy, err = templateUsers(templates, servers, tpl.TemplateId); err != nil {
+		logger.Error("list-template-users", "error", err)
+	}
+	if v.parents, err = templates.ListSummaries(); err != nil {
+		logger.Error("list-templates", "error", err)
+	}
+	return templateEmbed(tpl, v)
+}
+
+func templateEmbed(tpl *resources.Template, v templateView) (embeds []*discordgo.MessageEmbed, components []discordgo.MessageComponent) {
+	s := &v.effective
+	description := gameModeDescription(s.GameMode)
+	if v.resolveErr != nil {
+		description += "\n\n⚠️ The inherited settings can not be resolved, only the own settings are shown. Error: " + v.resolveErr.Error()
+	}
+	if w := usageWarning(v.usedBy); w != "" {
+		description += "\n\n" + w
+	}
+	overrides := tpl.Overrides()
+	// source tells whether a setting of a template with a parent is inherited or overridden
+	source := func(name string) string {
+		if from, ok := v.inherited[name]; ok {
+			return "\n*inherited from " + from + "*"
+		}
+		if tpl.ParentId != "" && slices.Contains(overrides, name) {
+			return "\n*overridden*"
+		}
+		return ""
+	}
 	embeds = append(embeds, &discordgo.MessageEmbed{
-		Color: ColorDarkGrey,
-		Title: s.Name,
+		Color:       ColorDarkGrey,
+		Title:       s.Name,
+		Description: description,
 		Fields: []*discordgo.MessageEmbedField{{
-			Name:  "ID",
-			Value: s.TemplateId,
+			Name:   "ID",
+			Value:  s.TemplateId,
+			Inline: true,
+		}, {
+			Name:   "Version",
+			Value:  strconv.Itoa(s.Version),
+			Inline: true,
+		}, {
+			Name:   "Parent",
+			Value:  parentName(*tpl, v.parents),
+			Inline: true,
+		}, {
+			Name:  "Used by",
+			Value: usedBySummary(*s, v.usedBy),
 		}, {
 			Name:   "Server Name Template",
-			Value:  "`" + valOrNotSet(s.ServerNameTemplate) + "`",
+			Value:  "`" + valOrNotSet(s.ServerNameTemplate) + "`" + source(resources.SettingServerName),
+			Inline: true,
+		}, {
+			Name:   "Server Name Preview",
+			Value:  serverNamePreview(*s, v.usedBy),
 			Inline: true,
 		}, {
 			Name:   "Welcome Message",
-			Value:  "`" + valOrNotSet(s.WelcomeMessage) + "`",
+			Value:  "`" + valOrNotSet(s.WelcomeMessage) + "`" + source(resources.SettingWelcomeMessage),
 			Inline: false,
+		}, {
+			Name:   "Broadcast messages",
+			Value:  strconv.Itoa(len(s.BroadcastMessage)) + source(resources.SettingBroadcastMessages),
+			Inline: true,
 		}, {
 			Name:   "Autobalance Threshold",
-			Value:  strconv.Itoa(s.AutoBalanceThreshold),
+			Value:  valOrNotSet(optionalIntString(s.AutoBalanceThreshold)) + source(resources.SettingAutoBalanceThreshold),
 			Inline: true,
 		}, {
 			Name:   "Teamswitch cooldown",
-			Value:  strconv.Itoa(s.TeamSwitchCooldown),
+			Value:  valOrNotSet(optionalIntString(s.TeamSwitchCooldown)) + source(resources.SettingTeamSwitchCooldown),
+			Inline: true,
+		}, {
+			Name:   "Autobalance",
+			Value:  valOrNotSet(optionalBoolString(s.AutoBalanceEnabled)) + source(resources.SettingAutoBalanceEnabled),
+			Inline: true,
+		}, {
+			Name:   "Vote kick",
+			Value:  valOrNotSet(optionalBoolString(s.VoteKickEnabled)) + source(resources.SettingVoteKickEnabled),
+			Inline: true,
+		}, {
+			Name:   "Idle autokick (minutes)",
+			Value:  valOrNotSet(optionalIntString(s.IdleAutoKickTime)) + source(resources.SettingIdleAutoKickTime),
+			Inline: true,
+		}, {
+			Name:   "Max ping autokick (ms)",
+			Value:  valOrNotSet(optionalIntString(s.MaxPingAutoKick)) + source(resources.SettingMaxPingAutoKick),
 			Inline: true,
+		}, {
+			Name:   "Queue length",
+			Value:  valOrNotSet(optionalIntString(s.QueueLength)) + source(resources.SettingQueueLength),
+			Inline: true,
+		}, {
+			Name:   "VIP slots",
+			Value:  valOrNotSet(optionalIntString(s.VipSlots)) + source(resources.SettingVipSlots),
+			Inline: true,
+		}, {
+			Name:   "Map rotation",
+			Value:  mapRotationSummary(*s, 900) + source(resources.SettingMapRotation),
+			Inline: false,
 		}, {
 			Name:   "Profanity filter",
-			Value:  strings.Join(s.ProfanityFilter, "\n"),
+			Value:  truncateField(strings.Join(s.ProfanityFilter, "\n"), 990) + source(resources.SettingProfanityFilter),
 			Inline: false,
 		}},
 	})
@@ -191,6 +388,11 @@ func templateEmbed(s *resources.Template) (embeds []*discordgo.MessageEmbed, com
 				CustomID: customId(templatesPrefix, "set-thresholds", s.TemplateId),
 				Style:    discordgo.SecondaryButton,
 			},
+			discordgo.Button{
+				Label:    "Set Server Settings",
+				CustomID: customId(templatesPrefix, "set-server-settings", s.TemplateId),
+				Style:    discordgo.SecondaryButton,
+			},
 			discordgo.Button{
 				Label:    "Set Profanity filter",
 				CustomID: customId(templatesPrefix, "set-profanity-filter", s.TemplateId),
@@ -202,18 +404,218 @@ func templateEmbed(s *resources.Template) (embeds []*discordgo.MessageEmbed, com
 				Style:    discordgo.SecondaryButton,
 			},
 		}},
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    "Edit Map Rotation",
+				CustomID: customId(templatesPrefix, "maps", s.TemplateId),
+				Style:    discordgo.SecondaryButton,
+			},
+			discordgo.Button{
+				Label:    "Delete Template",
+				CustomID: customId(templatesPrefix, "delete", s.TemplateId),
+				Style:    discordgo.DangerButton,
+			},
+		}},
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			gameModeSelect(*tpl),
+		}},
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			parentSelect(*tpl, v.parents),
+		}},
 	}...)
 	return
 }
 
+// noParent is the option of the parent select to not inherit from any template.
+const noParent = "none"
+
+func parentName(tpl resources.Template, templates []resources.Summary) string {
+	if tpl.ParentId == "" {
+		return "none"
+	}
+	for _, t := range templates {
+		if t.Id == tpl.ParentId {
+			return t.Name
+		}
+	}
+	return tpl.ParentId
+}
+
+// parentSelect offers the other templates as parent. Select menus are limited to 25 options, the current parent is
+// always offered first.
+func parentSelect(tpl resources.Template, templates []resources.Summary) discordgo.SelectMenu {
+	options := []discordgo.SelectMenuOption{{Label: "No parent template", Value: noParent, Default: tpl.ParentId == ""}}
+	option := func(t resources.Summary) discordgo.SelectMenuOption {
+		return discordgo.SelectMenuOption{Label: "Inherit from " + t.Name, Value: t.Id, Default: t.Id == tpl.ParentId}
+	}
+	for _, t := range templates {
+		if t.Id == tpl.ParentId {
+			options = append(options, option(t))
+		}
+	}
+	for _, t := range templates {
+		if len(options) == 25 {
+			break
+		}
+		if t.Id != tpl.TemplateId && t.Id != tpl.ParentId {
+			options = append(options, option(t))
+		}
+	}
+	return discordgo.SelectMenu{
+		MenuType: discordgo.StringSelectMenu,
+		CustomID: customId(templatesPrefix, "parent", tpl.TemplateId),
+		Options:  options,
+	}
+}
+
+func (c *TemplatesCommand) onParentSelect(s *discordgo.Session, i *discordgo.InteractionCreate, tplId string) {
+	tpl, err := c.templates.Find(tplId)
+	if err != nil {
+		c.logger.Error("find-template", "error", err)
+		storageErrorResponse(s, i.Interaction, "There was an error fetching template details.", err)
+		return
+	}
+	if tpl == nil {
+		ErrorResponse(s, i.Interaction, "Could not find template with ID "+tplId)
+		return
+	}
+	tpl.ParentId = ""
+	if v := i.MessageComponentData().Values[0]; v != noParent {
+		tpl.ParentId = v
+	}
+	if err := tpl.Validate(); err != nil {
+		ErrorResponse(s, i.Interaction, "The parent template can not be used. Error: "+err.Error())
+		return
+	}
+	if _, err := tpl.Chain(c.templates.Find); err != nil {
+		ErrorResponse(s, i.Interaction, "The parent template can not be used. Error: "+err.Error())
+		return
+	}
+	tpl.Version++
+	if err := c.templates.Save(*tpl); err != nil {
+		c.logger.Error("save-template", "error", err)
+		storageErrorResponse(s, i.Interaction, "There was an error saving the template.", err)
+		return
+	}
+	embeds, components := renderTemplate(c.logger, c.templates, c.servers, tpl)
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     embeds,
+			Components: components,
+		},
+	})
+	if err != nil {
+		c.logger.Error("edit-response", "error", err)
+	}
+}
+
+// truncateField shortens a value to the given number of characters, to fit into an embed field.
+func truncateField(v string, length int) string {
+	if r := []rune(v); len(r) > length {
+		return string(r[:length-1]) + "…"
+	}
+	return v
+}
+
+// usedBySummary lists the servers using the template with the version applied to them, or the inheriting template
+// they run.
+func usedBySummary(tpl resources.Template, usedBy []resources.Server) string {
+	if len(usedBy) == 0 {
+		return "Not applied to any server"
+	}
+	var b strings.Builder
+	for idx, u := range usedBy {
+		line := fmt.Sprintf("%s (version %d, applied <t:%d:R>)", u.Name, u.AppliedTemplate.Version, u.AppliedTemplate.AppliedAt.Unix())
+		if u.AppliedTemplate.TemplateId != tpl.TemplateId {
+			line = fmt.Sprintf("%s (through %s, applied <t:%d:R>)", u.Name, u.AppliedTemplate.Values.Name, u.AppliedTemplate.AppliedAt.Unix())
+		} else if u.AppliedTemplate.Version < tpl.Version {
+			line += " — outdated"
+		}
+		if b.Len()+len(line) > 950 {
+			b.WriteString(fmt.Sprintf("… and %d more", len(usedBy)-idx))
+			break
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// gameModeDescription states the game mode preset of a template.
+func gameModeDescription(m crcon.GameMode) string {
+	if m == "" {
+		return "🎮 **Any game mode** — the maps of the rotation are played as they are."
+	}
+	return fmt.Sprintf("🎮 **%s preset** — only %s maps are played, the server switches to one when the template is applied.", gameModeName(m), m)
+}
+
+func gameModeName(m crcon.GameMode) string {
+	return strings.ToUpper(string(m[:1])) + string(m[1:])
+}
+
+func gameModeSelect(tpl resources.Template) discordgo.SelectMenu {
+	options := []discordgo.SelectMenuOption{{Label: "Any game mode", Value: filterAll, Default: tpl.GameMode == ""}}
+	for _, m := range resources.GameModes {
+		options = append(options, discordgo.SelectMenuOption{Label: gameModeName(m) + " preset", Value: string(m), Default: tpl.GameMode == m})
+	}
+	return discordgo.SelectMenu{
+		MenuType: discordgo.StringSelectMenu,
+		CustomID: customId(templatesPrefix, "game-mode", tpl.TemplateId),
+		Options:  options,
+	}
+}
+
+func (c *TemplatesCommand) onGameModeSelect(s *discordgo.Session, i *discordgo.InteractionCreate, tplId string) {
+	tpl, err := c.templates.Find(tplId)
+	if err != nil {
+		c.logger.Error("find-template", "error", err)
+		storageErrorResponse(s, i.Interaction, "There was an error fetching template details.", err)
+		return
+	}
+	if tpl == nil {
+		ErrorResponse(s, i.Interaction, "Could not find template with ID "+tplId)
+		return
+	}
+	tpl.GameMode = ""
+	if v := i.MessageComponentData().Values[0]; v != filterAll {
+		tpl.GameMode = crcon.GameMode(v)
+	}
+	removeStartingMapOutsideRotation(tpl)
+	if err := tpl.Validate(); err != nil {
+		ErrorResponse(s, i.Interaction, "The game mode can not be used with the map rotation of this template. Add maps of the game mode or clear the rotation first. Error: "+err.Error())
+		return
+	}
+	tpl.Version++
+	if err := c.templates.Save(*tpl); err != nil {
+		c.logger.Error("save-template", "error", err)
+		storageErrorResponse(s, i.Interaction, "There was an error saving the template.", err)
+		return
+	}
+	embeds, components := renderTemplate(c.logger, c.templates, c.servers, tpl)
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     embeds,
+			Components: components,
+		},
+	})
+	if err != nil {
+		c.logger.Error("edit-response", "error", err)
+	}
+}
+
 func (c *TemplatesCommand) onRefreshClick(s *discordgo.Session, i *discordgo.InteractionCreate, tplId string) {
 	tpl, err := c.templates.Find(tplId)
 	if err != nil {
 		c.logger.Error("find-template", "error", err)
-		ErrorResponse(s, i.Interaction, "There was an error fetching template details. Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "There was an error fetching template details.", err)
+		return
+	}
+	if tpl == nil {
+		ErrorResponse(s, i.Interaction, "Could not find template with ID "+tplId)
 		return
 	}
-	embeds, components := templateEmbed(tpl)
+	embeds, components := renderTemplate(c.logger, c.templates, c.servers, tpl)
 	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseUpdateMessage,
 		Data: &discordgo.InteractionResponseData{
@@ -241,10 +643,11 @@ func messagesModal(tpl resources.Template) *discordgo.InteractionResponseData {
 			}},
 			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
 				discordgo.TextInput{
-					CustomID: "server-name-template",
-					Label:    "Server Name Template",
-					Style:    discordgo.TextInputShort,
-					Value:    tpl.ServerNameTemplate,
+					CustomID:    "server-name-template",
+					Label:       "Server Name Template",
+					Style:       discordgo.TextInputShort,
+					Placeholder: "{server} #{index} | {event} on {weekday}",
+					Value:       tpl.ServerNameTemplate,
 				},
 			}},
 		},
@@ -254,24 +657,46 @@ func messagesModal(tpl resources.Template) *discordgo.InteractionResponseData {
 
 func thresholdsModal(tpl resources.Template) *discordgo.InteractionResponseData {
 	return &discordgo.InteractionResponseData{
-		Title: "Set Messages",
+		Title: "Set Thresholds",
 		Components: []discordgo.MessageComponent{
 			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
 				discordgo.TextInput{
-					CustomID:  "team-switch-cooldown",
-					Label:     "Teamswitch Cooldown (seconds)",
-					Style:     discordgo.TextInputShort,
-					Value:     strconv.Itoa(tpl.TeamSwitchCooldown),
-					MaxLength: 3,
+					CustomID:    "team-switch-cooldown",
+					Label:       "Teamswitch Cooldown (seconds)",
+					Placeholder: "Leave empty to keep the server's setting",
+					Style:       discordgo.TextInputShort,
+					Value:       optionalIntString(tpl.TeamSwitchCooldown),
+					MaxLength:   3,
 				},
 			}},
 			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
 				discordgo.TextInput{
-					CustomID:  "auto-balance-threshold",
-					Label:     "Autobalance Threshold",
-					Style:     discordgo.TextInputShort,
-					Value:     strconv.Itoa(tpl.AutoBalanceThreshold),
-					MaxLength: 2,
+					CustomID:    "auto-balance-threshold",
+					Label:       "Autobalance Threshold",
+					Placeholder: "Leave empty to keep the server's setting",
+					Style:       discordgo.TextInputShort,
+					Value:       optionalIntString(tpl.AutoBalanceThreshold),
+					MaxLength:   2,
+				},
+			}},
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.TextInput{
+					CustomID:    "auto-balance-enabled",
+					Label:       "Autobalance enabled (on/off)",
+					Placeholder: "Leave empty to keep the server's setting",
+					Style:       discordgo.TextInputShort,
+					Value:       optionalBoolString(tpl.AutoBalanceEnabled),
+					MaxLength:   5,
+				},
+			}},
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.TextInput{
+					CustomID:    "vote-kick-enabled",
+					Label:       "Vote kick enabled (on/off)",
+					Placeholder: "Leave empty to keep the server's setting",
+					Style:       discordgo.TextInputShort,
+					Value:       optionalBoolString(tpl.VoteKickEnabled),
+					MaxLength:   5,
 				},
 			}},
 		},
@@ -279,6 +704,31 @@ func thresholdsModal(tpl resources.Template) *discordgo.InteractionResponseData
 	}
 }
 
+func serverSettingsModal(tpl resources.Template) *discordgo.InteractionResponseData {
+	input := func(id, label string, v *int, maxLength int) discordgo.ActionsRow {
+		return discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.TextInput{
+				CustomID:    id,
+				Label:       label,
+				Placeholder: "Leave empty to keep the server's setting",
+				Style:       discordgo.TextInputShort,
+				Value:       optionalIntString(v),
+				MaxLength:   maxLength,
+			},
+		}}
+	}
+	return &discordgo.InteractionResponseData{
+		Title: "Set Server Settings",
+		Components: []discordgo.MessageComponent{
+			input("idle-auto-kick-time", "Idle autokick (minutes, 0 to disable)", tpl.IdleAutoKickTime, 4),
+			input("max-ping-auto-kick", "Max ping autokick (ms, 0 to disable)", tpl.MaxPingAutoKick, 4),
+			input("queue-length", fmt.Sprintf("Queue length (0-%d)", resources.MaxQueueLength), tpl.QueueLength, 1),
+			input("vip-slots", "VIP slots", tpl.VipSlots, 3),
+		},
+		CustomID: customId(templatesPrefix, "confirm-server-settings", tpl.Id()),
+	}
+}
+
 func profanityFilterModal(tpl resources.Template) *discordgo.InteractionResponseData {
 	return &discordgo.InteractionResponseData{
 		Title: "Set Messages",
@@ -302,7 +752,7 @@ func (c *TemplatesCommand) onSetModal(s *discordgo.Session, i *discordgo.Interac
 	tpl, err := c.templates.Find(tplId)
 	if err != nil {
 		c.logger.Error("find-template", "error", err)
-		ErrorResponse(s, i.Interaction, "There was an error fetching template details. Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "There was an error fetching template details.", err)
 		return
 	}
 	if tpl == nil {
@@ -328,8 +778,22 @@ func (c *TemplatesCommand) OnMessageComponent(s *discordgo.Session, i *discordgo
 		c.onSetModal(s, i, peek, messagesModal)
 	} else if matchesId(id, customId(templatesPrefix, "set-thresholds")) {
 		c.onSetModal(s, i, peek, thresholdsModal)
+	} else if matchesId(id, customId(templatesPrefix, "set-server-settings")) {
+		c.onSetModal(s, i, peek, serverSettingsModal)
 	} else if matchesId(id, customId(templatesPrefix, "set-profanity-filter")) {
 		c.onSetModal(s, i, peek, profanityFilterModal)
+	} else if matchesId(id, customId(templatesPrefix, "game-mode")) {
+		c.onGameModeSelect(s, i, peek)
+	} else if matchesId(id, customId(templatesPrefix, "parent")) {
+		c.onParentSelect(s, i, peek)
+	} else if matchesId(id, customId(templatesPrefix, "delete")) {
+		c.onDeleteClick(s, i, peek)
+	} else if matchesId(id, customId(templatesPrefix, "confirm-delete")) {
+		c.onConfirmDelete(s, i, peek)
+	} else if matchesId(id, customId(templatesPrefix, "maps")) {
+		c.onMapPickerOpen(s, i, peek)
+	} else if strings.HasPrefix(id, customId(templatesPrefix, "maps-")) {
+		c.onMapPickerComponent(s, i)
 	}
 }
 
@@ -337,29 +801,63 @@ func (c *TemplatesCommand) OnModalSubmit(s *discordgo.Session, i *discordgo.Inte
 	id := i.ModalSubmitData().CustomID
 	peek, _ := peekId(id)
 	if matchesId(id, customId(templatesPrefix, "confirm-messages")) {
-		onConfirm(c.logger, c.templates, s, i, peek, func(tpl *resources.Template, d messagesData) {
-			tpl.WelcomeMessage = d.WelcomeMessage
-			tpl.ServerNameTemplate = d.ServerNameTemplate
-		})
+		onConfirm(c.logger, c.templates, c.servers, s, i, peek, updateMessages)
 	} else if matchesId(id, customId(templatesPrefix, "confirm-thresholds")) {
-		onConfirm(c.logger, c.templates, s, i, peek, func(tpl *resources.Template, d thresholdsData) {
-			tpl.TeamSwitchCooldown = d.teamSwitchCooldown()
-			tpl.AutoBalanceThreshold = d.autoBalanceThreshold()
-		})
+		onConfirm(c.logger, c.templates, c.servers, s, i, peek, updateThresholds)
+	} else if matchesId(id, customId(templatesPrefix, "confirm-server-settings")) {
+		onConfirm(c.logger, c.templates, c.servers, s, i, peek, updateServerSettings)
 	} else if matchesId(id, customId(templatesPrefix, "confirm-profanity-filter")) {
-		onConfirm(c.logger, c.templates, s, i, peek, func(tpl *resources.Template, d profanityData) {
-			tpl.ProfanityFilter = d.ProfanityFilter()
-		})
+		onConfirm(c.logger, c.templates, c.servers, s, i, peek, updateProfanityFilter)
 	}
 }
 
-type TemplateUpdate[T any] func(tpl *resources.Template, d T)
+func updateMessages(tpl *resources.Template, d messagesData) error {
+	tpl.WelcomeMessage = d.WelcomeMessage
+	tpl.ServerNameTemplate = d.ServerNameTemplate
+	return nil
+}
 
-func onConfirm[T any](logger *slog.Logger, templates internal.Storage[resources.Template], s *discordgo.Session, i *discordgo.InteractionCreate, tplId string, update TemplateUpdate[T]) {
+func updateThresholds(tpl *resources.Template, d thresholdsData) (err error) {
+	if tpl.TeamSwitchCooldown, err = parseOptionalInt("Teamswitch cooldown", d.TeamSwitchCooldown); err != nil {
+		return err
+	}
+	if tpl.AutoBalanceThreshold, err = parseOptionalInt("Autobalance threshold", d.AutoBalanceThreshold); err != nil {
+		return err
+	}
+	if tpl.AutoBalanceEnabled, err = parseOptionalBool("Autobalance enabled", d.AutoBalanceEnabled); err != nil {
+		return err
+	}
+	tpl.VoteKickEnabled, err = parseOptionalBool("Vote kick enabled", d.VoteKickEnabled)
+	return err
+}
+
+func updateServerSettings(tpl *resources.Template, d serverSettingsData) (err error) {
+	if tpl.IdleAutoKickTime, err = parseOptionalInt("Idle autokick", d.IdleAutoKickTime); err != nil {
+		return err
+	}
+	if tpl.MaxPingAutoKick, err = parseOptionalInt("Max ping autokick", d.MaxPingAutoKick); err != nil {
+		return err
+	}
+	if tpl.QueueLength, err = parseOptionalInt("Queue length", d.QueueLength); err != nil {
+		return err
+	}
+	tpl.VipSlots, err = parseOptionalInt("VIP slots", d.VipSlots)
+	return err
+}
+
+func updateProfanityFilter(tpl *resources.Template, d profanityData) error {
+	tpl.ProfanityFilter = d.ProfanityFilter()
+	return nil
+}
+
+// TemplateUpdate applies the data of a modal to the template. An error rejects the input, the template is not saved.
+type TemplateUpdate[T any] func(tpl *resources.Template, d T) error
+
+func onConfirm[T any](logger *slog.Logger, templates internal.Repository[resources.Template], servers internal.Repository[resources.Server], s *discordgo.Session, i *discordgo.InteractionCreate, tplId string, update TemplateUpdate[T]) {
 	tpl, err := templates.Find(tplId)
 	if err != nil {
 		logger.Error("find-template", "error", err)
-		ErrorResponse(s, i.Interaction, "There was an error fetching template details. Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "There was an error fetching template details.", err)
 		return
 	}
 	if tpl == nil {
@@ -368,18 +866,26 @@ func onConfirm[T any](logger *slog.Logger, templates internal.Storage[resources.
 	}
 	var d T
 	if err := marshaller.Unmarshal(i.ModalSubmitData().Components, &d); err != nil {
-		logger.Error("parse-data", err)
+		logger.Error("parse-data", "error", err)
 		ErrorResponse(s, i.Interaction, "Unknown error: "+err.Error())
 		return
 	}
-	update(tpl, d)
+	if err := update(tpl, d); err != nil {
+		ErrorResponse(s, i.Interaction, "The provided values are not valid. Error: "+err.Error())
+		return
+	}
+	if err := tpl.Validate(); err != nil {
+		ErrorResponse(s, i.Interaction, "The provided values are not valid. Error: "+err.Error())
+		return
+	}
+	tpl.Version++
 	err = templates.Save(*tpl)
 	if err != nil {
 		logger.Error("save-template", "error", err)
-		ErrorResponse(s, i.Interaction, "There was an error saving the template. Error: "+err.Error())
+		storageErrorResponse(s, i.Interaction, "There was an error saving the template.", err)
 		return
 	}
-	embeds, components := templateEmbed(tpl)
+	embeds, components := renderTemplate(logger, templates, servers, tpl)
 	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseUpdateMessage,
 		Data: &discordgo.InteractionResponseData{
@@ -395,3 +901,84 @@ func onConfirm[T any](logger *slog.Logger, templates internal.Storage[resources.
 func (c *TemplatesCommand) CanHandle(customId string) bool {
 	return strings.HasPrefix(customId, templatesPrefix)
 }
+
+func (c *TemplatesCommand) onDeleteClick(s *discordgo.Session, i *discordgo.InteractionCreate, tplId string) {
+	tpl, err := c.templates.Find(tplId)
+	if err != nil {
+		c.logger.Error("find-template", "error", err)
+		storageErrorResponse(s, i.Interaction, "There was an error fetching template details.", err)
+		return
+	}
+	if tpl == nil {
+		ErrorResponse(s, i.Interaction, "Could not find template with ID "+tplId)
+		return
+	}
+	users, err := templateUsers(c.templates, c.servers, tplId)
+	if err != nil {
+		c.logger.Error("list-template-users", "error", err)
+		storageErrorResponse(s, i.Interaction, "There was an error listing the servers using the template.", err)
+		return
+	}
+	content := "Do you really want to delete the template **" + tpl.Name + "**?"
+	if len(users) != 0 {
+		content += fmt.Sprintf(" It is used by %d server(s): %s. The servers keep their settings, but can not be reset to the template and drift reports can not be resolved by re-applying it.", len(users), usedNames(users))
+	}
+	children, err := templateChildren(c.templates, tplId)
+	if err != nil {
+		c.logger.Error("list-template-children", "error", err)
+		storageErrorResponse(s, i.Interaction, "There was an error listing the templates inheriting from the template.", err)
+		return
+	}
+	if len(children) != 0 {
+		var names []string
+		for _, child := range children {
+			names = append(names, "**"+child.Name+"**")
+		}
+		content += " The templates " + strings.Join(names, ", ") + " inherit from it and can not be applied until another parent is selected for them."
+	}
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags:   discordgo.MessageFlagsEphemeral,
+			Content: content,
+			Components: []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Delete template",
+					CustomID: customId(templatesPrefix, "confirm-delete", tplId),
+					Style:    discordgo.DangerButton,
+				},
+			}}},
+		},
+	})
+	if err != nil {
+		c.logger.Error("send-response", "error", err)
+	}
+}
+
+func (c *TemplatesCommand) onConfirmDelete(s *discordgo.Session, i *discordgo.InteractionCreate, tplId string) {
+	tpl, err := c.templates.Find(tplId)
+	if err != nil {
+		c.logger.Error("find-template", "error", err)
+		storageErrorResponse(s, i.Interaction, "There was an error fetching template details.", err)
+		return
+	}
+	if tpl == nil {
+		ErrorResponse(s, i.Interaction, "Could not find template with ID "+tplId)
+		return
+	}
+	if err := c.templates.Delete(tplId); err != nil {
+		c.logger.Error("delete-template", "error", err)
+		storageErrorResponse(s, i.Interaction, "There was an error deleting the template.", err)
+		return
+	}
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    "The template **" + tpl.Name + "** was deleted.",
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+	if err != nil {
+		c.logger.Error("edit-response", "error", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"log/slog"
 	"os"
+	"slices"
 )
 
 type Discord struct {
@@ -16,13 +17,78 @@ type EmbedMessage struct {
 	MessageId string
 }
 
+type Backup struct {
+	// Directory where backup archives are written to, defaults to ./backups/
+	Directory string `json:"directory"`
+	// IntervalHours defines how often a backup is created automatically. Scheduled backups are disabled when 0.
+	IntervalHours int `json:"interval_hours"`
+	// Retention is the number of backup archives to keep, older ones are deleted. All are kept when 0.
+	Retention int `json:"retention"`
+	// ExcludeCredentials removes all server credentials and the Discord token from backup archives.
+	ExcludeCredentials bool `json:"exclude_credentials"`
+	// EncryptionKey is used to encrypt backup archives. Credentials are always excluded when no key is set.
+	EncryptionKey string `json:"encryption_key"`
+}
+
+type Alerts struct {
+	// ChannelId is the channel where background jobs report problems to.
+	ChannelId string `json:"channel_id"`
+	// RoleId is an optional role which is mentioned in alerts.
+	RoleId string `json:"role_id"`
+}
+
+type HealthCheck struct {
+	// IntervalMinutes defines how often the credentials of all servers are verified. Disabled when 0.
+	IntervalMinutes int `json:"interval_minutes"`
+}
+
+type DriftCheck struct {
+	// IntervalMinutes defines how often the settings of servers are compared with the template applied last. Disabled
+	// when 0.
+	IntervalMinutes int `json:"interval_minutes"`
+}
+
 type Config struct {
 	Discord      *Discord      `json:"discord"`
 	EmbedMessage *EmbedMessage `json:"embed_message"`
+	Backup       *Backup       `json:"backup"`
+	// PermissionStrictness defines how CRCon API keys with more than the required permissions are handled, defaults
+	// to StrictnessWarn.
+	PermissionStrictness Strictness   `json:"permission_strictness"`
+	HealthCheck          *HealthCheck `json:"health_check"`
+	DriftCheck           *DriftCheck  `json:"drift_check"`
+	Alerts               *Alerts      `json:"alerts"`
+	// SecretRoles are the roles allowed to reveal stored secrets, like API keys and passwords. Members with the
+	// administrator permission can always reveal secrets.
+	SecretRoles []string `json:"secret_roles"`
 
 	path string
 }
 
+func (c *Config) Strictness() Strictness {
+	if c.PermissionStrictness == StrictnessReject {
+		return StrictnessReject
+	}
+	return StrictnessWarn
+}
+
+// CanRevealSecrets returns true if one of the given roles is allowed to reveal stored secrets.
+func (c *Config) CanRevealSecrets(roles []string) bool {
+	for _, r := range roles {
+		if slices.Contains(c.SecretRoles, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Replace sets all settings to the ones of the given config, e.g. when a backup is restored. The file the config is
+// stored in is kept.
+func (c *Config) Replace(o Config) {
+	o.path = c.path
+	*c = o
+}
+
 func (c *Config) Save() error {
 	config, err := json.MarshalIndent(c, "", "  ")
 	if err != nil {
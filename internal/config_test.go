@@ -29,4 +29,22 @@ var _ = Describe("Config", func() {
 			Expect(err).ToNot(HaveOccurred())
 		})
 	})
+
+	Describe("CanRevealSecrets", func() {
+		It("allows configured roles only", func() {
+			c := internal.Config{SecretRoles: []string{"1", "2"}}
+
+			Expect(c.CanRevealSecrets([]string{"3", "2"})).To(BeTrue())
+			Expect(c.CanRevealSecrets([]string{"3"})).To(BeFalse())
+			Expect(c.CanRevealSecrets(nil)).To(BeFalse())
+		})
+	})
+
+	Describe("Strictness", func() {
+		It("warns unless rejecting is configured", func() {
+			Expect((&internal.Config{}).Strictness()).To(Equal(internal.StrictnessWarn))
+			Expect((&internal.Config{PermissionStrictness: "unknown"}).Strictness()).To(Equal(internal.StrictnessWarn))
+			Expect((&internal.Config{PermissionStrictness: internal.StrictnessReject}).Strictness()).To(Equal(internal.StrictnessReject))
+		})
+	})
 })
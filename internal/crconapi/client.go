@@ -0,0 +1,83 @@
+package crconapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/floriansw/go-crcon"
+	"net/http"
+	"net/url"
+)
+
+// Client calls endpoints of the CRCon API, which are not covered by the go-crcon client. Errors are reported like the
+// ones of go-crcon, e.g. crcon.ErrForbidden when the API key lacks a permission.
+type Client struct {
+	hc      http.Client
+	baseUrl string
+	apiKey  string
+}
+
+func NewClient(hc http.Client, baseUrl, apiKey string) *Client {
+	return &Client{
+		hc:      hc,
+		baseUrl: baseUrl,
+		apiKey:  apiKey,
+	}
+}
+
+type response[T any] struct {
+	Result T      `json:"result"`
+	Failed bool   `json:"failed"`
+	Error  string `json:"error"`
+}
+
+func (c *Client) get(ctx context.Context, endpoint string, res any) error {
+	return c.do(ctx, http.MethodGet, endpoint, nil, res)
+}
+
+func (c *Client) post(ctx context.Context, endpoint string, body any) error {
+	return c.do(ctx, http.MethodPost, endpoint, body, nil)
+}
+
+func (c *Client) do(ctx context.Context, method, endpoint string, body any, res any) error {
+	u, err := url.JoinPath(c.baseUrl, "/api/", endpoint)
+	if err != nil {
+		return err
+	}
+	var b []byte
+	if body != nil {
+		if b, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	r, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Authorization", "Bearer "+c.apiKey)
+	r.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.hc.Do(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusForbidden {
+		return crcon.ErrForbidden
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	var envelope response[json.RawMessage]
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return err
+	}
+	if envelope.Failed {
+		return fmt.Errorf("%w: %s", crcon.ErrFailed, envelope.Error)
+	}
+	if res == nil || len(envelope.Result) == 0 {
+		return nil
+	}
+	return json.Unmarshal(envelope.Result, res)
+}
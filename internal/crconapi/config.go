@@ -0,0 +1,32 @@
+package crconapi
+
+import (
+	"context"
+	"github.com/floriansw/go-crcon"
+)
+
+type autoBroadcastConfigResponse struct {
+	Enabled   bool `json:"enabled"`
+	Randomize bool `json:"randomize"`
+	Messages  []struct {
+		Message string `json:"message"`
+		TimeSec int    `json:"time_sec"`
+	} `json:"messages"`
+}
+
+func (c *Client) AutoBroadcastConfig(ctx context.Context) (crcon.AutoBroadcastConfig, error) {
+	var res autoBroadcastConfigResponse
+	if err := c.get(ctx, "get_auto_broadcasts_config", &res); err != nil {
+		return crcon.AutoBroadcastConfig{}, err
+	}
+	config := crcon.AutoBroadcastConfig{Enabled: res.Enabled, Randomize: res.Randomize}
+	for _, m := range res.Messages {
+		config.Messages = append(config.Messages, crcon.BroadcastMessage{Message: m.Message, TimeSec: m.TimeSec})
+	}
+	return config, nil
+}
+
+func (c *Client) Profanities(ctx context.Context) ([]string, error) {
+	var res []string
+	return res, c.get(ctx, "get_profanities", &res)
+}
@@ -0,0 +1,13 @@
+package crconapi_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestCRConApi(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CRCon API Suite")
+}
@@ -0,0 +1,52 @@
+package crconapi
+
+import (
+	"context"
+	"fmt"
+)
+
+// ConnectionInfo describes one game server of a CRCon installation.
+type ConnectionInfo struct {
+	Name         string `json:"name"`
+	Port         int    `json:"port"`
+	Link         string `json:"link"`
+	ServerNumber int    `json:"server_number"`
+}
+
+// ConnectionInfo returns the game server served at the base URL of the client.
+func (c *Client) ConnectionInfo(ctx context.Context) (ConnectionInfo, error) {
+	var res ConnectionInfo
+	return res, c.get(ctx, "get_connection_info", &res)
+}
+
+// ServerList returns the other game servers managed by the same CRCon installation.
+func (c *Client) ServerList(ctx context.Context) ([]ConnectionInfo, error) {
+	var res []ConnectionInfo
+	return res, c.get(ctx, "get_server_list", &res)
+}
+
+// ResolveInstance returns the URL of the game server with the given number. The base URL is returned when it already
+// serves the requested server.
+func (c *Client) ResolveInstance(ctx context.Context, number int) (string, error) {
+	current, err := c.ConnectionInfo(ctx)
+	if err != nil {
+		return "", err
+	}
+	if current.ServerNumber == number {
+		return c.baseUrl, nil
+	}
+	others, err := c.ServerList(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, o := range others {
+		if o.ServerNumber != number {
+			continue
+		}
+		if o.Link == "" {
+			return "", fmt.Errorf("CRCon does not know the URL of server %d, please configure it in the CRCon settings or use the URL of the server directly", number)
+		}
+		return o.Link, nil
+	}
+	return "", fmt.Errorf("CRCon does not manage a server with number %d", number)
+}
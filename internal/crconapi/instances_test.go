@@ -0,0 +1,64 @@
+package crconapi_test
+
+import (
+	"context"
+	"errors"
+	"github.com/floriansw/go-crcon"
+	"github.com/floriansw/hll-discord-server-watcher/internal/crconapi"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+)
+
+var _ = Describe("Instances", func() {
+	var server *httptest.Server
+	var client *crconapi.Client
+
+	BeforeEach(func() {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/api/get_connection_info", func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer key" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			_, _ = w.Write([]byte(`{"result":{"name":"Server 1","port":7010,"link":"https://crcon.example.com","server_number":1},"failed":false}`))
+		})
+		mux.HandleFunc("/api/get_server_list", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"result":[{"name":"Server 2","port":7011,"link":"https://crcon.example.com:8011","server_number":2},{"name":"Server 3","port":7012,"link":"","server_number":3}],"failed":false}`))
+		})
+		server = httptest.NewServer(mux)
+		client = crconapi.NewClient(http.Client{}, server.URL, "key")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("resolves the base URL for the server it serves", func() {
+		u, err := client.ResolveInstance(context.Background(), 1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(u).To(Equal(server.URL))
+	})
+
+	It("resolves the link of another server", func() {
+		u, err := client.ResolveInstance(context.Background(), 2)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(u).To(Equal("https://crcon.example.com:8011"))
+	})
+
+	It("fails for servers without a link", func() {
+		_, err := client.ResolveInstance(context.Background(), 3)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("fails for unknown servers", func() {
+		_, err := client.ResolveInstance(context.Background(), 4)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("reports a rejected API key as forbidden", func() {
+		_, err := crconapi.NewClient(http.Client{}, server.URL, "other").ConnectionInfo(context.Background())
+		Expect(errors.Is(err, crcon.ErrForbidden)).To(BeTrue())
+	})
+})
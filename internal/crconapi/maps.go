@@ -0,0 +1,31 @@
+package crconapi
+
+import (
+	"context"
+	"github.com/floriansw/go-crcon"
+)
+
+type mapResponse struct {
+	Id          string `json:"id"`
+	GameMode    string `json:"game_mode"`
+	Environment string `json:"environment"`
+	Name        string `json:"pretty_name"`
+}
+
+// Maps returns all maps the server can play.
+func (c *Client) Maps(ctx context.Context) ([]crcon.Map, error) {
+	var res []mapResponse
+	if err := c.get(ctx, "get_maps", &res); err != nil {
+		return nil, err
+	}
+	var maps []crcon.Map
+	for _, m := range res {
+		maps = append(maps, crcon.Map{Id: m.Id, Name: m.Name, GameMode: m.GameMode, Environment: m.Environment})
+	}
+	return maps, nil
+}
+
+// SetMapRotation replaces the map rotation of the server with the maps of the given IDs.
+func (c *Client) SetMapRotation(ctx context.Context, ids []string) error {
+	return c.post(ctx, "set_maprotation", map[string][]string{"map_names": ids})
+}
@@ -0,0 +1,27 @@
+package crconapi
+
+import "context"
+
+func (c *Client) SetAutoBalanceEnabled(ctx context.Context, enabled bool) error {
+	return c.post(ctx, "set_autobalance_enabled", map[string]bool{"value": enabled})
+}
+
+func (c *Client) SetIdleAutoKickTime(ctx context.Context, minutes int) error {
+	return c.post(ctx, "set_idle_autokick_time", map[string]int{"minutes": minutes})
+}
+
+func (c *Client) SetMaxPingAutoKick(ctx context.Context, ms int) error {
+	return c.post(ctx, "set_max_ping_autokick", map[string]int{"max_ms": ms})
+}
+
+func (c *Client) SetQueueLength(ctx context.Context, length int) error {
+	return c.post(ctx, "set_queue_length", map[string]int{"value": length})
+}
+
+func (c *Client) SetVipSlots(ctx context.Context, slots int) error {
+	return c.post(ctx, "set_vip_slots_num", map[string]int{"value": slots})
+}
+
+func (c *Client) SetVoteKickEnabled(ctx context.Context, enabled bool) error {
+	return c.post(ctx, "set_votekick_enabled", map[string]bool{"value": enabled})
+}
@@ -0,0 +1,72 @@
+package crconapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/floriansw/go-crcon"
+	"github.com/floriansw/hll-discord-server-watcher/internal/crconapi"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io"
+	"net/http"
+	"net/http/httptest"
+)
+
+var _ = Describe("Settings", func() {
+	var server *httptest.Server
+	var client *crconapi.Client
+	var requests map[string]map[string]any
+
+	BeforeEach(func() {
+		requests = map[string]map[string]any{}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, _ := io.ReadAll(r.Body)
+			var body map[string]any
+			_ = json.Unmarshal(b, &body)
+			requests[r.Method+" "+r.URL.Path] = body
+			if r.URL.Path == "/api/get_auto_broadcasts_config" {
+				_, _ = w.Write([]byte(`{"result":{"enabled":true,"randomize":false,"messages":[{"time_sec":60,"message":"Join our Discord"}]},"failed":false}`))
+				return
+			}
+			if r.URL.Path == "/api/set_queue_length" {
+				_, _ = w.Write([]byte(`{"result":null,"failed":true,"error":"value must be between 1 and 6"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"result":null,"failed":false}`))
+		}))
+		client = crconapi.NewClient(http.Client{}, server.URL, "key")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("posts the settings", func() {
+		Expect(client.SetAutoBalanceEnabled(context.Background(), true)).To(Succeed())
+		Expect(client.SetMaxPingAutoKick(context.Background(), 500)).To(Succeed())
+
+		Expect(requests).To(Equal(map[string]map[string]any{
+			"POST /api/set_autobalance_enabled": {"value": true},
+			"POST /api/set_max_ping_autokick":   {"max_ms": float64(500)},
+		}))
+	})
+
+	It("posts the map rotation", func() {
+		Expect(client.SetMapRotation(context.Background(), []string{"foy_warfare", "kursk_offensive_ger"})).To(Succeed())
+
+		Expect(requests["POST /api/set_maprotation"]).To(Equal(map[string]any{"map_names": []any{"foy_warfare", "kursk_offensive_ger"}}))
+	})
+
+	It("reads the auto broadcast config", func() {
+		c, err := client.AutoBroadcastConfig(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c).To(Equal(crcon.AutoBroadcastConfig{Enabled: true, Messages: []crcon.BroadcastMessage{{Message: "Join our Discord", TimeSec: 60}}}))
+	})
+
+	It("reports failed requests", func() {
+		err := client.SetQueueLength(context.Background(), 10)
+		Expect(errors.Is(err, crcon.ErrFailed)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("value must be between 1 and 6"))
+	})
+})
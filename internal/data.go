@@ -8,9 +8,26 @@ const (
 	HLLFileId = "1"
 )
 
+// Storage persists entities by their ID. IDs are obtained from resources.ParseId, hence the storage never builds a
+// path from an unchecked ID.
 type Storage[T resources.Identifiable] interface {
+	Find(id resources.Id) (*T, error)
+	Save(entity T) error
+	Delete(id resources.Id) error
+	List() ([]resources.Id, error)
+}
+
+// Repository provides access to the entities of a Storage by the plain IDs used in interactions, which are parsed
+// before the storage is accessed, and additionally provides cheap access to the summaries of all stored entities.
+type Repository[T resources.Summarizable] interface {
 	Find(id string) (*T, error)
 	Save(entity T) error
 	Delete(id string) error
 	List() ([]string, error)
+	// ListSummaries returns the summaries of all entities, sorted by name.
+	ListSummaries() ([]resources.Summary, error)
+	// Search returns the summaries of all entities whose name or ID contains the query, sorted by name.
+	Search(query string) ([]resources.Summary, error)
+	// Invalidate drops the index, e.g. when the underlying storage was changed by someone else.
+	Invalidate()
 }
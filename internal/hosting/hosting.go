@@ -0,0 +1,35 @@
+package hosting
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrUnsupported is returned for actions the hosting provider does not offer.
+	ErrUnsupported = errors.New("the hosting provider does not support this action")
+)
+
+type Status string
+
+const (
+	StatusRunning  = Status("running")
+	StatusStarting = Status("starting")
+	StatusStopping = Status("stopping")
+	StatusOffline  = Status("offline")
+)
+
+type ServerInfo struct {
+	Name     string
+	Password string
+}
+
+// Provider manages a single game server at the service it is hosted with.
+type Provider interface {
+	ServerInfo(ctx context.Context) (*ServerInfo, error)
+	SetServerInfo(ctx context.Context, name, password string) error
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+	Restart(ctx context.Context) error
+	Status(ctx context.Context) (Status, error)
+}
@@ -0,0 +1,13 @@
+package hosting_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestHosting(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Hosting Suite")
+}
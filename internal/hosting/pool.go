@@ -0,0 +1,75 @@
+package hosting
+
+import (
+	"github.com/floriansw/go-tcadmin"
+	"github.com/floriansw/hll-discord-server-watcher/resources"
+	"sync"
+)
+
+// TCAdminPool keeps one client per server, so that the session of the panel is reused across requests instead of
+// logging in every time. The clients log in again on their own when the session expired. Requests of servers sharing a
+// panel account are serialised, as the clients are not safe for concurrent use and parallel logins of the same account
+// trip rate limits of hosts.
+type TCAdminPool struct {
+	newClient func(creds resources.TCAdminCredentials) TCAdminClient
+
+	mu       sync.Mutex
+	clients  map[string]pooledTCAdmin
+	accounts map[string]*sync.Mutex
+}
+
+type pooledTCAdmin struct {
+	creds  resources.TCAdminCredentials
+	client TCAdminClient
+}
+
+func NewTCAdminPool(f func(creds resources.TCAdminCredentials) TCAdminClient) *TCAdminPool {
+	return &TCAdminPool{
+		newClient: f,
+		clients:   map[string]pooledTCAdmin{},
+		accounts:  map[string]*sync.Mutex{},
+	}
+}
+
+// Client returns the client of the server. A new client is created when the credentials of the server changed.
+func (p *TCAdminPool) Client(serverId string, creds resources.TCAdminCredentials) TCAdminClient {
+	creds.Health = resources.CredentialsHealth{}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	account := creds.PanelAddress() + "#" + creds.Username
+	l, ok := p.accounts[account]
+	if !ok {
+		l = &sync.Mutex{}
+		p.accounts[account] = l
+	}
+	c, ok := p.clients[serverId]
+	if !ok || c.creds != creds {
+		c = pooledTCAdmin{creds: creds, client: p.newClient(creds)}
+		p.clients[serverId] = c
+	}
+	return &serialisedTCAdmin{lock: l, client: c.client}
+}
+
+type serialisedTCAdmin struct {
+	lock   *sync.Mutex
+	client TCAdminClient
+}
+
+func (s *serialisedTCAdmin) ServerInfo(serviceId string) (*tcadmin.ServerInfo, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.client.ServerInfo(serviceId)
+}
+
+func (s *serialisedTCAdmin) SetServerInfo(serviceId string, name, pw string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.client.SetServerInfo(serviceId, name, pw)
+}
+
+func (s *serialisedTCAdmin) Restart(serviceId string) (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.client.Restart(serviceId)
+}
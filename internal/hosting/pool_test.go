@@ -0,0 +1,90 @@
+package hosting_test
+
+import (
+	"github.com/floriansw/go-tcadmin"
+	"github.com/floriansw/hll-discord-server-watcher/internal/hosting"
+	"github.com/floriansw/hll-discord-server-watcher/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type fakeTCAdmin struct {
+	running    *atomic.Int32
+	concurrent *atomic.Int32
+}
+
+func (f *fakeTCAdmin) ServerInfo(serviceId string) (*tcadmin.ServerInfo, error) {
+	if n := f.running.Add(1); n > 1 {
+		f.concurrent.Add(1)
+	}
+	time.Sleep(5 * time.Millisecond)
+	f.running.Add(-1)
+	return &tcadmin.ServerInfo{Name: serviceId}, nil
+}
+
+func (f *fakeTCAdmin) SetServerInfo(serviceId string, name, pw string) error {
+	return nil
+}
+
+func (f *fakeTCAdmin) Restart(serviceId string) (string, error) {
+	return "", nil
+}
+
+var _ = Describe("TCAdminPool", func() {
+	var created int
+	var running, concurrent atomic.Int32
+	var pool *hosting.TCAdminPool
+	creds := resources.TCAdminCredentials{BaseUrl: "https://panel.example.com", ServiceId: "1", Username: "user", Password: "pass"}
+
+	BeforeEach(func() {
+		created = 0
+		running.Store(0)
+		concurrent.Store(0)
+		pool = hosting.NewTCAdminPool(func(creds resources.TCAdminCredentials) hosting.TCAdminClient {
+			created++
+			return &fakeTCAdmin{running: &running, concurrent: &concurrent}
+		})
+	})
+
+	It("reuses the client of a server", func() {
+		pool.Client("server", creds)
+		withHealth := creds
+		withHealth.Health.LastError = "failed"
+		pool.Client("server", withHealth)
+
+		Expect(created).To(Equal(1))
+	})
+
+	It("creates a new client when the credentials changed", func() {
+		pool.Client("server", creds)
+		changed := creds
+		changed.Password = "new"
+		pool.Client("server", changed)
+
+		Expect(created).To(Equal(2))
+	})
+
+	It("serialises requests of the same account", func() {
+		other := creds
+		other.ServiceId = "2"
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				c := creds
+				if i%2 == 0 {
+					c = other
+				}
+				_, _ = pool.Client("server-"+c.ServiceId, c).ServerInfo(c.ServiceId)
+			}(i)
+		}
+		wg.Wait()
+
+		Expect(created).To(Equal(2))
+		Expect(concurrent.Load()).To(BeZero())
+	})
+})
@@ -0,0 +1,148 @@
+package hosting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/floriansw/hll-discord-server-watcher/resources"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+var (
+	ErrUnauthorized = errors.New("the API key was rejected by the panel")
+)
+
+type pterodactyl struct {
+	hc    http.Client
+	creds resources.PterodactylCredentials
+}
+
+// NewPterodactyl manages a server through the client API of a Pterodactyl panel.
+func NewPterodactyl(hc http.Client, creds resources.PterodactylCredentials) Provider {
+	return &pterodactyl{
+		hc:    hc,
+		creds: creds,
+	}
+}
+
+type pterodactylErrors struct {
+	Errors []struct {
+		Code   string `json:"code"`
+		Status string `json:"status"`
+		Detail string `json:"detail"`
+	} `json:"errors"`
+}
+
+type startupVariables struct {
+	Data []struct {
+		Attributes struct {
+			EnvVariable string `json:"env_variable"`
+			ServerValue string `json:"server_value"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+type serverResources struct {
+	Attributes struct {
+		CurrentState string `json:"current_state"`
+	} `json:"attributes"`
+}
+
+func (p *pterodactyl) ServerInfo(ctx context.Context) (*ServerInfo, error) {
+	var v startupVariables
+	if err := p.do(ctx, http.MethodGet, "startup", nil, &v); err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	for _, d := range v.Data {
+		values[d.Attributes.EnvVariable] = d.Attributes.ServerValue
+	}
+	name, ok := values[p.creds.NameVariable]
+	if !ok {
+		return nil, fmt.Errorf("the server has no startup variable %s", p.creds.NameVariable)
+	}
+	password, ok := values[p.creds.PasswordVariable]
+	if !ok {
+		return nil, fmt.Errorf("the server has no startup variable %s", p.creds.PasswordVariable)
+	}
+	return &ServerInfo{Name: name, Password: password}, nil
+}
+
+func (p *pterodactyl) SetServerInfo(ctx context.Context, name, password string) error {
+	for _, v := range [][2]string{{p.creds.NameVariable, name}, {p.creds.PasswordVariable, password}} {
+		err := p.do(ctx, http.MethodPut, "startup/variable", map[string]string{"key": v[0], "value": v[1]}, nil)
+		if err != nil {
+			return fmt.Errorf("setting %s: %w", v[0], err)
+		}
+	}
+	return nil
+}
+
+func (p *pterodactyl) Start(ctx context.Context) error {
+	return p.power(ctx, "start")
+}
+
+func (p *pterodactyl) Stop(ctx context.Context) error {
+	return p.power(ctx, "stop")
+}
+
+func (p *pterodactyl) Restart(ctx context.Context) error {
+	return p.power(ctx, "restart")
+}
+
+func (p *pterodactyl) power(ctx context.Context, signal string) error {
+	return p.do(ctx, http.MethodPost, "power", map[string]string{"signal": signal}, nil)
+}
+
+func (p *pterodactyl) Status(ctx context.Context) (Status, error) {
+	var r serverResources
+	if err := p.do(ctx, http.MethodGet, "resources", nil, &r); err != nil {
+		return "", err
+	}
+	return Status(r.Attributes.CurrentState), nil
+}
+
+func (p *pterodactyl) do(ctx context.Context, method, path string, body any, res any) error {
+	u, err := url.JoinPath(p.creds.BaseUrl, "/api/client/servers", p.creds.ServerId, path)
+	if err != nil {
+		return err
+	}
+	var rb io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		rb = bytes.NewReader(b)
+	}
+	r, err := http.NewRequestWithContext(ctx, method, u, rb)
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Authorization", "Bearer "+p.creds.ApiKey)
+	r.Header.Set("Accept", "application/json")
+	r.Header.Set("Content-Type", "application/json")
+	resp, err := p.hc.Do(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return ErrUnauthorized
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var e pterodactylErrors
+		if err := json.NewDecoder(resp.Body).Decode(&e); err == nil && len(e.Errors) != 0 {
+			return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, e.Errors[0].Detail)
+		}
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if res == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(res)
+}
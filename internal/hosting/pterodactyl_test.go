@@ -0,0 +1,140 @@
+package hosting_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/floriansw/hll-discord-server-watcher/internal/hosting"
+	"github.com/floriansw/hll-discord-server-watcher/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// fakePanel is a stand-in for the client API of a Pterodactyl panel serving a single server.
+type fakePanel struct {
+	mu        sync.Mutex
+	variables map[string]string
+	state     string
+	signals   []string
+}
+
+func (f *fakePanel) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("Authorization") != "Bearer ptlc_valid" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch r.Method + " " + r.URL.Path {
+	case "GET /api/client/servers/1a2b3c4d/startup":
+		var data []map[string]any
+		for k, v := range f.variables {
+			data = append(data, map[string]any{"attributes": map[string]string{"env_variable": k, "server_value": v}})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": data})
+	case "PUT /api/client/servers/1a2b3c4d/startup/variable":
+		var b map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&b)
+		if _, ok := f.variables[b["key"]]; !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors":[{"code":"BadRequestHttpException","status":"400","detail":"The environment variable you are trying to edit does not exist."}]}`))
+			return
+		}
+		f.variables[b["key"]] = b["value"]
+		w.WriteHeader(http.StatusOK)
+	case "POST /api/client/servers/1a2b3c4d/power":
+		var b map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&b)
+		f.signals = append(f.signals, b["signal"])
+		w.WriteHeader(http.StatusNoContent)
+	case "GET /api/client/servers/1a2b3c4d/resources":
+		_ = json.NewEncoder(w).Encode(map[string]any{"attributes": map[string]string{"current_state": f.state}})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+var _ = Describe("Pterodactyl", func() {
+	ctx := context.Background()
+	var panel *fakePanel
+	var server *httptest.Server
+	var creds resources.PterodactylCredentials
+
+	BeforeEach(func() {
+		panel = &fakePanel{
+			variables: map[string]string{"SERVER_NAME": "My Server", "SERVER_PASSWORD": "secret", "MAX_PLAYERS": "100"},
+			state:     "running",
+		}
+		server = httptest.NewServer(panel)
+		creds = resources.PterodactylCredentials{
+			BaseUrl:          server.URL,
+			ServerId:         "1a2b3c4d",
+			ApiKey:           "ptlc_valid",
+			NameVariable:     "SERVER_NAME",
+			PasswordVariable: "SERVER_PASSWORD",
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("reads the server info from the startup variables", func() {
+		si, err := hosting.NewPterodactyl(http.Client{}, creds).ServerInfo(ctx)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(si).To(Equal(&hosting.ServerInfo{Name: "My Server", Password: "secret"}))
+	})
+
+	It("fails when a startup variable does not exist", func() {
+		creds.NameVariable = "HOSTNAME"
+
+		_, err := hosting.NewPterodactyl(http.Client{}, creds).ServerInfo(ctx)
+
+		Expect(err).To(MatchError(ContainSubstring("HOSTNAME")))
+	})
+
+	It("sets name and password", func() {
+		Expect(hosting.NewPterodactyl(http.Client{}, creds).SetServerInfo(ctx, "New Name", "")).ToNot(HaveOccurred())
+
+		Expect(panel.variables).To(HaveKeyWithValue("SERVER_NAME", "New Name"))
+		Expect(panel.variables).To(HaveKeyWithValue("SERVER_PASSWORD", ""))
+	})
+
+	It("reports errors of the panel", func() {
+		creds.PasswordVariable = "PASSWORD"
+
+		err := hosting.NewPterodactyl(http.Client{}, creds).SetServerInfo(ctx, "New Name", "")
+
+		Expect(err).To(MatchError(ContainSubstring("does not exist")))
+	})
+
+	It("sends power signals", func() {
+		p := hosting.NewPterodactyl(http.Client{}, creds)
+
+		Expect(p.Start(ctx)).ToNot(HaveOccurred())
+		Expect(p.Stop(ctx)).ToNot(HaveOccurred())
+		Expect(p.Restart(ctx)).ToNot(HaveOccurred())
+
+		Expect(panel.signals).To(Equal([]string{"start", "stop", "restart"}))
+	})
+
+	It("returns the status", func() {
+		panel.state = "offline"
+
+		s, err := hosting.NewPterodactyl(http.Client{}, creds).Status(ctx)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s).To(Equal(hosting.StatusOffline))
+	})
+
+	It("rejects invalid API keys", func() {
+		creds.ApiKey = "ptlc_invalid"
+
+		_, err := hosting.NewPterodactyl(http.Client{}, creds).Status(ctx)
+
+		Expect(err).To(MatchError(hosting.ErrUnauthorized))
+	})
+})
@@ -0,0 +1,144 @@
+package hosting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/floriansw/go-tcadmin"
+	"html"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+)
+
+// TCAdminClient is the subset of the TCAdmin client used by the provider.
+type TCAdminClient interface {
+	ServerInfo(serviceId string) (*tcadmin.ServerInfo, error)
+	SetServerInfo(serviceId string, name, pw string) error
+	Restart(serviceId string) (string, error)
+}
+
+type tcAdmin struct {
+	client    TCAdminClient
+	serviceId string
+}
+
+// NewTCAdmin manages the service with the given ID. TCAdmin does not offer starting, stopping or the status of a
+// service through its web interface.
+func NewTCAdmin(c TCAdminClient, serviceId string) Provider {
+	return &tcAdmin{
+		client:    c,
+		serviceId: serviceId,
+	}
+}
+
+func (t *tcAdmin) ServerInfo(ctx context.Context) (res *ServerInfo, err error) {
+	err = withContext(ctx, func() error {
+		si, err := t.client.ServerInfo(t.serviceId)
+		if err != nil {
+			return err
+		}
+		res = &ServerInfo{Name: si.Name, Password: si.Password}
+		return nil
+	})
+	return
+}
+
+func (t *tcAdmin) SetServerInfo(ctx context.Context, name, password string) error {
+	return withContext(ctx, func() error {
+		return t.client.SetServerInfo(t.serviceId, name, password)
+	})
+}
+
+func (t *tcAdmin) Start(ctx context.Context) error {
+	return ErrUnsupported
+}
+
+func (t *tcAdmin) Stop(ctx context.Context) error {
+	return ErrUnsupported
+}
+
+func (t *tcAdmin) Restart(ctx context.Context) error {
+	return withContext(ctx, func() error {
+		_, err := t.client.Restart(t.serviceId)
+		return err
+	})
+}
+
+func (t *tcAdmin) Status(ctx context.Context) (Status, error) {
+	return "", ErrUnsupported
+}
+
+// withContext returns when f finished or ctx is done, whichever comes first. The TCAdmin client does not accept a
+// context, f keeps running in the background until the timeout of its HTTP client.
+func withContext(ctx context.Context, f func() error) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- f()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+var (
+	ErrTCAdminConfigNotFound = errors.New("the config editor of the service could not be found")
+
+	configEditorPattern = regexp.MustCompile(`MvcConfigEditor\.aspx\?([^"'\s<>]+)`)
+)
+
+// DetectTCAdminConfig logs in to the panel and searches the page of the service for the link to its config editor,
+// which contains the game, mod and file IDs of the game server.
+func DetectTCAdminConfig(hc http.Client, baseUrl, serviceId, username, password string) (gameId, modId, fileId string, err error) {
+	hc.Jar, _ = cookiejar.New(nil)
+	hc.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	loginUrl, err := url.JoinPath(baseUrl, "/Aspx/Interface/Base/Login.aspx")
+	if err != nil {
+		return "", "", "", err
+	}
+	r, err := http.NewRequest(http.MethodGet, loginUrl, nil)
+	if err != nil {
+		return "", "", "", err
+	}
+	r.SetBasicAuth(username, password)
+	res, err := hc.Do(r)
+	if err != nil {
+		return "", "", "", err
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusFound {
+		return "", "", "", errors.New("invalid username or password")
+	}
+
+	serviceUrl, err := url.JoinPath(baseUrl, "/Aspx/Interface/GameHosting/ServiceHome.aspx")
+	if err != nil {
+		return "", "", "", err
+	}
+	res, err = hc.Get(serviceUrl + "?" + url.Values{"serviceid": {serviceId}}.Encode())
+	if err != nil {
+		return "", "", "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", "", "", fmt.Errorf("unexpected status %d of the service page", res.StatusCode)
+	}
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", "", "", err
+	}
+	for _, m := range configEditorPattern.FindAllStringSubmatch(string(b), -1) {
+		q, err := url.ParseQuery(html.UnescapeString(m[1]))
+		if err != nil || q.Get("serviceid") != serviceId || q.Get("gameid") == "" || q.Get("fileid") == "" {
+			continue
+		}
+		return q.Get("gameid"), q.Get("modid"), q.Get("fileid"), nil
+	}
+	return "", "", "", ErrTCAdminConfigNotFound
+}
@@ -0,0 +1,54 @@
+package hosting_test
+
+import (
+	"github.com/floriansw/hll-discord-server-watcher/internal/hosting"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+)
+
+var _ = Describe("TCAdmin", func() {
+	var server *httptest.Server
+
+	BeforeEach(func() {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/panel/Aspx/Interface/Base/Login.aspx", func(w http.ResponseWriter, r *http.Request) {
+			if u, p, ok := r.BasicAuth(); !ok || u != "user" || p != "pass" {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "1", Path: "/"})
+			w.Header().Set("Location", "/Aspx/Interface/Base/Home.aspx")
+			w.WriteHeader(http.StatusFound)
+		})
+		mux.HandleFunc("/panel/Aspx/Interface/GameHosting/ServiceHome.aspx", func(w http.ResponseWriter, r *http.Request) {
+			if _, err := r.Cookie("session"); err != nil {
+				w.WriteHeader(http.StatusFound)
+				return
+			}
+			_, _ = w.Write([]byte(`<html><body>
+<a href="MvcConfigEditor.aspx?gameid=1&amp;modid=0&amp;fileid=9&amp;serviceid=99">Other</a>
+<a href="/Aspx/Interface/GameHosting/MvcConfigEditor.aspx?gameid=1098726660&amp;modid=0&amp;fileid=2&amp;serviceid=` + r.URL.Query().Get("serviceid") + `">Game.ini</a>
+</body></html>`))
+		})
+		server = httptest.NewTLSServer(mux)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("detects the config IDs of the service", func() {
+		gameId, modId, fileId, err := hosting.DetectTCAdminConfig(*server.Client(), server.URL+"/panel", "1234", "user", "pass")
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect([]string{gameId, modId, fileId}).To(Equal([]string{"1098726660", "0", "2"}))
+	})
+
+	It("rejects invalid credentials", func() {
+		_, _, _, err := hosting.DetectTCAdminConfig(*server.Client(), server.URL+"/panel", "1234", "user", "wrong")
+
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"github.com/floriansw/go-crcon"
+	"github.com/floriansw/hll-discord-server-watcher/resources"
+	"slices"
+	"time"
+)
+
+const (
+	// StrictnessReject rejects CRCon API keys which are superuser keys or grant more than the required permissions.
+	StrictnessReject = Strictness("reject")
+	// StrictnessWarn accepts such API keys, but warns about the excess permissions.
+	StrictnessWarn = Strictness("warn")
+)
+
+type Strictness string
+
+var (
+	RequiredPermissions = []string{
+		"can_change_team_switch_cooldown",
+		"can_view_team_switch_cooldown",
+		"can_view_autobalance_threshold",
+		"can_view_autobalance_enabled",
+		"can_change_autobalance_enabled",
+		"can_change_autobalance_threshold",
+		"can_change_idle_autokick_time",
+		"can_change_max_ping_autokick",
+		"can_change_queue_length",
+		"can_change_vip_slots",
+		"can_change_votekick_enabled",
+		"can_change_welcome_message",
+		"can_view_welcome_message",
+		"can_change_auto_broadcast_config",
+		"can_view_auto_broadcast_config",
+		"can_view_admins",
+		"can_add_admin_roles",
+		"can_remove_admin_roles",
+		"can_unban_profanities",
+		"can_view_profanities",
+		"can_ban_profanities",
+		"can_change_profanities",
+		"can_view_playerids",
+		"can_view_all_maps",
+		"can_add_maps_to_rotation",
+		"can_remove_maps_from_rotation",
+		"can_change_current_map",
+		"can_view_gamestate",
+	}
+)
+
+// CheckPermissions compares the permissions of a CRCon API key with the RequiredPermissions.
+func CheckPermissions(p crcon.OwnPermissions) resources.PermissionStatus {
+	s := resources.PermissionStatus{
+		CheckedAt: time.Now(),
+		Superuser: p.Superuser,
+	}
+	for _, r := range RequiredPermissions {
+		if slices.Contains(p.Permissions, r) {
+			s.Ok = append(s.Ok, r)
+		} else {
+			s.Missing = append(s.Missing, r)
+		}
+	}
+	for _, g := range p.Permissions {
+		if !slices.Contains(RequiredPermissions, g) {
+			s.Extra = append(s.Extra, g)
+		}
+	}
+	slices.Sort(s.Extra)
+	return s
+}
@@ -0,0 +1,32 @@
+package internal_test
+
+import (
+	"github.com/floriansw/go-crcon"
+	"github.com/floriansw/hll-discord-server-watcher/internal"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"slices"
+)
+
+var _ = Describe("CheckPermissions", func() {
+	It("accepts exactly the required permissions", func() {
+		s := internal.CheckPermissions(crcon.OwnPermissions{Permissions: internal.RequiredPermissions})
+
+		Expect(s.LeastPrivilege()).To(BeTrue())
+		Expect(s.Ok).To(Equal(internal.RequiredPermissions))
+	})
+
+	It("reports missing and extra permissions", func() {
+		s := internal.CheckPermissions(crcon.OwnPermissions{Permissions: slices.Concat(internal.RequiredPermissions[1:], []string{"can_ban_players"})})
+
+		Expect(s.LeastPrivilege()).To(BeFalse())
+		Expect(s.Missing).To(Equal(internal.RequiredPermissions[:1]))
+		Expect(s.Extra).To(Equal([]string{"can_ban_players"}))
+	})
+
+	It("flags superuser keys", func() {
+		s := internal.CheckPermissions(crcon.OwnPermissions{Superuser: true, Permissions: internal.RequiredPermissions})
+
+		Expect(s.LeastPrivilege()).To(BeFalse())
+	})
+})
@@ -0,0 +1,242 @@
+package rcon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/floriansw/go-crcon"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var ErrUnsupported = errors.New("not supported by the RCON protocol")
+
+// Client manages a Hell Let Loose server through its RCON interface directly, without a Community RCon installation.
+// Each call opens a new session, so that no connection needs to be kept alive between interactions.
+type Client struct {
+	address  string
+	password string
+}
+
+func NewClient(address, password string) *Client {
+	return &Client{
+		address:  address,
+		password: password,
+	}
+}
+
+func (c *Client) session(ctx context.Context, f func(*conn) error) error {
+	rc, err := dial(ctx, c.address, c.password)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return f(rc)
+}
+
+func (c *Client) exec(ctx context.Context, cmd string) error {
+	return c.session(ctx, func(rc *conn) error {
+		return rc.exec(ctx, cmd)
+	})
+}
+
+func (c *Client) SetTeamSwitchCooldown(ctx context.Context, minutes int) error {
+	return c.exec(ctx, "SetTeamSwitchCooldown "+strconv.Itoa(minutes))
+}
+
+func (c *Client) SetAutoBalanceThreshold(ctx context.Context, maxDiff int) error {
+	return c.exec(ctx, "SetAutoBalanceThreshold "+strconv.Itoa(maxDiff))
+}
+
+func (c *Client) SetWelcomeMessage(ctx context.Context, message string) error {
+	return c.exec(ctx, "Say "+message)
+}
+
+func (c *Client) SetAutoBalanceEnabled(ctx context.Context, enabled bool) error {
+	return c.exec(ctx, "SetAutoBalanceEnabled "+onOff(enabled))
+}
+
+func (c *Client) SetIdleAutoKickTime(ctx context.Context, minutes int) error {
+	return c.exec(ctx, "SetKickIdleTime "+strconv.Itoa(minutes))
+}
+
+func (c *Client) SetMaxPingAutoKick(ctx context.Context, ms int) error {
+	return c.exec(ctx, "SetHighPing "+strconv.Itoa(ms))
+}
+
+func (c *Client) SetQueueLength(ctx context.Context, length int) error {
+	return c.exec(ctx, "SetMaxQueuedPlayers "+strconv.Itoa(length))
+}
+
+func (c *Client) SetVipSlots(ctx context.Context, slots int) error {
+	return c.exec(ctx, "SetNumVipSlots "+strconv.Itoa(slots))
+}
+
+func (c *Client) SetVoteKickEnabled(ctx context.Context, enabled bool) error {
+	return c.exec(ctx, "SetVoteKickEnabled "+onOff(enabled))
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+// WelcomeMessage is not supported, the server does not report its welcome message.
+func (c *Client) WelcomeMessage(context.Context) (string, error) {
+	return "", ErrUnsupported
+}
+
+// SetAutoBroadcastConfig sets the broadcast message of the server. The server has a single broadcast message instead
+// of rotating ones, the messages are shown together. A disabled config clears the broadcast message.
+func (c *Client) SetAutoBroadcastConfig(ctx context.Context, config crcon.AutoBroadcastConfig) error {
+	var messages []string
+	if config.Enabled {
+		for _, m := range config.Messages {
+			messages = append(messages, m.Message)
+		}
+	}
+	return c.exec(ctx, "Broadcast "+strings.Join(messages, "\n"))
+}
+
+func (c *Client) Profanities(ctx context.Context) (res []string, err error) {
+	err = c.session(ctx, func(rc *conn) error {
+		res, err = rc.list(ctx, "Get Profanity")
+		return err
+	})
+	return
+}
+
+// AutoBroadcastConfig is not supported, the server does not report its broadcast message.
+func (c *Client) AutoBroadcastConfig(context.Context) (crcon.AutoBroadcastConfig, error) {
+	return crcon.AutoBroadcastConfig{}, ErrUnsupported
+}
+
+// SetProfanities replaces the banned words of the server with the given ones.
+func (c *Client) SetProfanities(ctx context.Context, prof []string) error {
+	return c.session(ctx, func(rc *conn) error {
+		current, err := rc.list(ctx, "Get Profanity")
+		if err != nil {
+			return err
+		}
+		var remove, add []string
+		for _, p := range current {
+			if !slices.Contains(prof, p) {
+				remove = append(remove, p)
+			}
+		}
+		for _, p := range prof {
+			if !slices.Contains(current, p) {
+				add = append(add, p)
+			}
+		}
+		if len(remove) != 0 {
+			if err := rc.exec(ctx, "UnbanProfanity "+strings.Join(remove, ",")); err != nil {
+				return err
+			}
+		}
+		if len(add) != 0 {
+			return rc.exec(ctx, "BanProfanity "+strings.Join(add, ","))
+		}
+		return nil
+	})
+}
+
+func (c *Client) ServerSettings(ctx context.Context) (res crcon.ServerSettings, err error) {
+	err = c.session(ctx, func(rc *conn) error {
+		ints := map[string]*int{
+			"Get AutoBalanceThreshold": &res.AutoBalanceThreshold,
+			"Get IdleTime":             &res.IdleAutoKickTime,
+			"Get HighPing":             &res.MaxPingAutoKick,
+			"Get MaxQueuedPlayers":     &res.QueueLength,
+			"Get TeamSwitchCooldown":   &res.TeamSwitchCooldown,
+			"Get NumVipSlots":          &res.VipSlotsNumber,
+		}
+		for cmd, v := range ints {
+			r, err := rc.command(ctx, cmd, nil)
+			if err != nil {
+				return err
+			}
+			if *v, err = strconv.Atoi(r); err != nil {
+				return err
+			}
+		}
+		bools := map[string]*bool{
+			"Get AutoBalanceEnabled": &res.AutoBalanceEnabled,
+			"Get VoteKickEnabled":    &res.VoteKickEnabled,
+		}
+		for cmd, v := range bools {
+			r, err := rc.command(ctx, cmd, nil)
+			if err != nil {
+				return err
+			}
+			*v = r == "on"
+		}
+		return nil
+	})
+	return
+}
+
+// PlayerIds returns the IDs of the connected players. The server lists them as "name : id".
+func (c *Client) PlayerIds(ctx context.Context) (ids []string, err error) {
+	err = c.session(ctx, func(rc *conn) error {
+		players, err := rc.list(ctx, "Get PlayerIds")
+		if err != nil {
+			return err
+		}
+		for _, p := range players {
+			i := strings.LastIndex(p, " : ")
+			if i == -1 {
+				continue
+			}
+			ids = append(ids, p[i+3:])
+		}
+		return nil
+	})
+	return
+}
+
+// GameState returns the current map, score and number of players. The server reports them as lines like
+// "Score: Allied: 2 - Axis: 3".
+func (c *Client) GameState(ctx context.Context) (res crcon.GameState, err error) {
+	err = c.session(ctx, func(rc *conn) error {
+		r, err := rc.command(ctx, "Get GameState", nil)
+		if err != nil {
+			return err
+		}
+		for _, line := range strings.Split(r, "\n") {
+			key, value, _ := strings.Cut(line, ":")
+			value = strings.TrimSpace(value)
+			switch key {
+			case "Players":
+				var allied, axis int
+				if _, err := fmt.Sscanf(value, "Allied: %d - Axis: %d", &allied, &axis); err == nil {
+					res.PlayerCount = allied + axis
+				}
+			case "Score":
+				_, _ = fmt.Sscanf(value, "Allied: %d - Axis: %d", &res.Score.Allied, &res.Score.Axis)
+			case "Map":
+				res.Map = mapFromId(value)
+			}
+		}
+		if res.Map.Id == "" {
+			return fmt.Errorf("the game state does not contain the current map: %s", r)
+		}
+		return nil
+	})
+	return
+}
+
+// OwnPermissions verifies the password. The RCON password grants access to all commands, so the permissions are the
+// ones of a superuser.
+func (c *Client) OwnPermissions(ctx context.Context) (crcon.OwnPermissions, error) {
+	err := c.session(ctx, func(*conn) error {
+		return nil
+	})
+	if err != nil {
+		return crcon.OwnPermissions{}, err
+	}
+	return crcon.OwnPermissions{Username: "RCON", Superuser: true}, nil
+}
@@ -0,0 +1,144 @@
+package rcon_test
+
+import (
+	"context"
+	"errors"
+	"github.com/floriansw/go-crcon"
+	"github.com/floriansw/hll-discord-server-watcher/internal/rcon"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"time"
+)
+
+var _ = Describe("Client", func() {
+	var server *fakeServer
+	var client *rcon.Client
+	var ctx context.Context
+	var cancel context.CancelFunc
+
+	BeforeEach(func() {
+		server = newFakeServer("secret")
+		client = rcon.NewClient(server.Address(), "secret")
+		ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	})
+
+	AfterEach(func() {
+		cancel()
+		server.Close()
+	})
+
+	It("rejects a wrong password", func() {
+		_, err := rcon.NewClient(server.Address(), "wrong").OwnPermissions(ctx)
+		Expect(errors.Is(err, rcon.ErrLoginFailed)).To(BeTrue())
+	})
+
+	It("reports the permissions of a superuser", func() {
+		p, err := client.OwnPermissions(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(p.Superuser).To(BeTrue())
+	})
+
+	It("sets the team switch cooldown and autobalance threshold", func() {
+		Expect(client.SetTeamSwitchCooldown(ctx, 10)).To(Succeed())
+		Expect(client.SetAutoBalanceThreshold(ctx, 3)).To(Succeed())
+
+		s, err := client.ServerSettings(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s.TeamSwitchCooldown).To(Equal(10))
+		Expect(s.AutoBalanceThreshold).To(Equal(3))
+		Expect(s.AutoBalanceEnabled).To(BeTrue())
+		Expect(s.VoteKickEnabled).To(BeFalse())
+		Expect(s.QueueLength).To(Equal(6))
+	})
+
+	It("sets the server settings", func() {
+		Expect(client.SetAutoBalanceEnabled(ctx, false)).To(Succeed())
+		Expect(client.SetIdleAutoKickTime(ctx, 15)).To(Succeed())
+		Expect(client.SetMaxPingAutoKick(ctx, 300)).To(Succeed())
+		Expect(client.SetQueueLength(ctx, 4)).To(Succeed())
+		Expect(client.SetVipSlots(ctx, 5)).To(Succeed())
+		Expect(client.SetVoteKickEnabled(ctx, true)).To(Succeed())
+
+		s, err := client.ServerSettings(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(s).To(Equal(crcon.ServerSettings{
+			AutoBalanceEnabled:   false,
+			AutoBalanceThreshold: 2,
+			IdleAutoKickTime:     15,
+			MaxPingAutoKick:      300,
+			QueueLength:          4,
+			TeamSwitchCooldown:   5,
+			VipSlotsNumber:       5,
+			VoteKickEnabled:      true,
+		}))
+	})
+
+	It("sets the welcome and broadcast messages", func() {
+		Expect(client.SetWelcomeMessage(ctx, "Welcome!")).To(Succeed())
+		Expect(client.SetAutoBroadcastConfig(ctx, crcon.AutoBroadcastConfig{Enabled: true, Messages: []crcon.BroadcastMessage{
+			{Message: "Join our Discord", TimeSec: 60},
+			{Message: "Have fun", TimeSec: 60},
+		}})).To(Succeed())
+
+		Expect(server.Commands()).To(Equal([]string{"Say Welcome!", "Broadcast Join our Discord\nHave fun"}))
+	})
+
+	It("replaces the profanities", func() {
+		Expect(client.SetProfanities(ctx, []string{"a", "b"})).To(Succeed())
+		Expect(client.SetProfanities(ctx, []string{"b", "c"})).To(Succeed())
+
+		Expect(server.Commands()).To(Equal([]string{
+			"Get Profanity", "BanProfanity a,b",
+			"Get Profanity", "UnbanProfanity a", "BanProfanity c",
+		}))
+		Expect(client.Profanities(ctx)).To(Equal([]string{"b", "c"}))
+	})
+
+	It("lists the IDs of the players", func() {
+		server.players = []string{"Alice : 76561198000000001", "Bob : 2 : 76561198000000002"}
+
+		ids, err := client.PlayerIds(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ids).To(Equal([]string{"76561198000000001", "76561198000000002"}))
+	})
+
+	It("derives the details of maps from their IDs", func() {
+		server.maps = []string{"foy_warfare_night", "kursk_offensive_ger", "stmariedumont_skirmish_dusk", "_custom"}
+
+		maps, err := client.Maps(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(maps).To(Equal([]crcon.Map{
+			{Id: "foy_warfare_night", Name: "Foy", GameMode: "warfare", Environment: "night"},
+			{Id: "kursk_offensive_ger", Name: "Kursk", GameMode: "offensive", Environment: "day"},
+			{Id: "stmariedumont_skirmish_dusk", Name: "Stmariedumont", GameMode: "skirmish", Environment: "dusk"},
+			{Id: "_custom", Name: "_custom", GameMode: "warfare", Environment: "day"},
+		}))
+	})
+
+	It("replaces the map rotation", func() {
+		server.maps = []string{"foy_warfare", "kursk_warfare", "utahbeach_warfare"}
+		server.rotation = []string{"foy_warfare", "kursk_warfare"}
+
+		Expect(client.SetMapRotation(ctx, []string{"utahbeach_warfare", "foy_warfare"})).To(Succeed())
+		Expect(client.SwitchMap(ctx, "utahbeach_warfare")).To(Succeed())
+
+		Expect(server.rotation).To(Equal([]string{"utahbeach_warfare", "foy_warfare"}))
+		rotation, err := client.MapRotation(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rotation).To(HaveLen(2))
+		Expect(rotation[0].Id).To(Equal("utahbeach_warfare"))
+		Expect(server.settings["Map"]).To(Equal("utahbeach_warfare"))
+
+		state, err := client.GameState(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(state.Map.Id).To(Equal("utahbeach_warfare"))
+		Expect(state.Map.GameMode).To(Equal("warfare"))
+		Expect(state.Score).To(Equal(crcon.Score{Allied: 2, Axis: 1}))
+		Expect(state.PlayerCount).To(Equal(7))
+	})
+
+	It("does not support reading the welcome message", func() {
+		_, err := client.WelcomeMessage(ctx)
+		Expect(errors.Is(err, rcon.ErrUnsupported)).To(BeTrue())
+	})
+})
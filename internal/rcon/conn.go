@@ -0,0 +1,154 @@
+package rcon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrLoginFailed = errors.New("the RCON password was rejected")
+	ErrFailed      = errors.New("the server rejected the command")
+)
+
+// idleTimeout is the time to wait for further data of a response. The protocol has no framing, a response of unknown
+// format is complete when the server stops sending.
+const idleTimeout = 250 * time.Millisecond
+
+// conn is an authenticated session with the RCON interface of a Hell Let Loose server. The server sends a key when the
+// connection is opened, all further messages in both directions are XOR-ed with it.
+type conn struct {
+	c   net.Conn
+	key []byte
+}
+
+func dial(ctx context.Context, address, password string) (*conn, error) {
+	var d net.Dialer
+	c, err := d.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = c.SetDeadline(deadline)
+	}
+	key := make([]byte, 4)
+	if _, err := io.ReadFull(c, key); err != nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("reading session key: %w", err)
+	}
+	rc := &conn{c: c, key: key}
+	res, err := rc.command(ctx, "Login "+password, isStatus)
+	if err != nil {
+		_ = rc.Close()
+		return nil, err
+	}
+	if res != "SUCCESS" {
+		_ = rc.Close()
+		return nil, ErrLoginFailed
+	}
+	return rc, nil
+}
+
+func (c *conn) Close() error {
+	return c.c.Close()
+}
+
+// command sends the command and returns the response. complete reports whether the response received so far is
+// complete, nil waits until the server stops sending.
+func (c *conn) command(ctx context.Context, cmd string, complete func(string) bool) (string, error) {
+	if _, err := c.c.Write(xor([]byte(cmd), c.key)); err != nil {
+		return "", err
+	}
+	var res []byte
+	buf := make([]byte, 32768)
+	for {
+		if len(res) > 0 {
+			deadline := time.Now().Add(idleTimeout)
+			if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+				deadline = d
+			}
+			_ = c.c.SetReadDeadline(deadline)
+		}
+		n, err := c.c.Read(buf)
+		res = append(res, buf[:n]...)
+		if err == nil {
+			if complete != nil && complete(string(xor(res, c.key))) {
+				break
+			}
+			continue
+		}
+		if len(res) > 0 && errors.Is(err, os.ErrDeadlineExceeded) && ctx.Err() == nil {
+			break
+		}
+		return "", err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = c.c.SetDeadline(deadline)
+	} else {
+		_ = c.c.SetDeadline(time.Time{})
+	}
+	return string(xor(res, c.key)), nil
+}
+
+// exec sends a command, which is answered with SUCCESS or FAIL.
+func (c *conn) exec(ctx context.Context, cmd string) error {
+	res, err := c.command(ctx, cmd, isStatus)
+	if err != nil {
+		return err
+	}
+	if res != "SUCCESS" {
+		return fmt.Errorf("%w: %s", ErrFailed, strings.SplitN(cmd, " ", 2)[0])
+	}
+	return nil
+}
+
+// list sends a command, which is answered with the number of entries followed by the tab separated entries.
+func (c *conn) list(ctx context.Context, cmd string) ([]string, error) {
+	res, err := c.command(ctx, cmd, func(r string) bool {
+		_, ok := parseList(r)
+		return ok && strings.HasSuffix(r, "\t")
+	})
+	if err != nil {
+		return nil, err
+	}
+	entries, ok := parseList(res)
+	if !ok {
+		return nil, fmt.Errorf("unexpected response to %s: %q", cmd, res)
+	}
+	return entries, nil
+}
+
+func isStatus(r string) bool {
+	return r == "SUCCESS" || r == "FAIL"
+}
+
+// parseList returns the entries of a list response and whether the response contains all of them.
+func parseList(r string) ([]string, bool) {
+	count, rest, _ := strings.Cut(r, "\t")
+	n, err := strconv.Atoi(count)
+	if err != nil {
+		return nil, false
+	}
+	if n == 0 {
+		return nil, true
+	}
+	entries := strings.Split(strings.TrimRight(rest, "\t\n"), "\t")
+	if len(entries) != n {
+		return nil, false
+	}
+	return entries, true
+}
+
+func xor(b, key []byte) []byte {
+	res := make([]byte, len(b))
+	for i := range b {
+		res[i] = b[i] ^ key[i%len(key)]
+	}
+	return res
+}
@@ -0,0 +1,189 @@
+package rcon_test
+
+import (
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// fakeServer is a stand-in for the RCON interface of a Hell Let Loose server.
+type fakeServer struct {
+	l        net.Listener
+	password string
+
+	mu          sync.Mutex
+	settings    map[string]string
+	profanities []string
+	players     []string
+	maps        []string
+	rotation    []string
+	commands    []string
+}
+
+func newFakeServer(password string) *fakeServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+	f := &fakeServer{
+		l:        l,
+		password: password,
+		settings: map[string]string{
+			"AutoBalanceThreshold": "2",
+			"IdleTime":             "10",
+			"HighPing":             "500",
+			"MaxQueuedPlayers":     "6",
+			"TeamSwitchCooldown":   "5",
+			"NumVipSlots":          "2",
+			"AutoBalanceEnabled":   "on",
+			"VoteKickEnabled":      "off",
+		},
+	}
+	go f.serve()
+	return f
+}
+
+func (f *fakeServer) Address() string {
+	return f.l.Addr().String()
+}
+
+func (f *fakeServer) Close() {
+	_ = f.l.Close()
+}
+
+func (f *fakeServer) Commands() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.commands)
+}
+
+func (f *fakeServer) serve() {
+	for {
+		c, err := f.l.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(c)
+	}
+}
+
+func (f *fakeServer) handle(c net.Conn) {
+	defer c.Close()
+	key := []byte{0x1f, 0x8b, 0x42, 0x07}
+	if _, err := c.Write(key); err != nil {
+		return
+	}
+	loggedIn := false
+	buf := make([]byte, 8192)
+	for {
+		n, err := c.Read(buf)
+		if err != nil {
+			return
+		}
+		cmd := string(xor(buf[:n], key))
+		var res string
+		if pw, ok := strings.CutPrefix(cmd, "Login "); ok {
+			loggedIn = pw == f.password
+			res = "FAIL"
+			if loggedIn {
+				res = "SUCCESS"
+			}
+		} else if !loggedIn {
+			return
+		} else {
+			res = f.execute(cmd)
+		}
+		if _, err := c.Write(xor([]byte(res), key)); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeServer) execute(cmd string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.commands = append(f.commands, cmd)
+	name, arg, _ := strings.Cut(cmd, " ")
+	switch name {
+	case "Get":
+		switch arg {
+		case "Profanity":
+			return list(f.profanities)
+		case "PlayerIds":
+			return list(f.players)
+		case "MapsForRotation":
+			return list(f.maps)
+		case "GameState":
+			return "Players: Allied: 3 - Axis: 4\nScore: Allied: 2 - Axis: 1\nRemaining Time: 0:42:10\nMap: " + f.settings["Map"] + "\nNext Map: " + f.settings["Map"]
+		}
+		if v, ok := f.settings[arg]; ok {
+			return v
+		}
+		return "FAIL"
+	case "SetTeamSwitchCooldown", "SetAutoBalanceThreshold", "SetHighPing", "SetMaxQueuedPlayers", "SetNumVipSlots":
+		if _, err := strconv.Atoi(arg); err != nil {
+			return "FAIL"
+		}
+		f.settings[strings.TrimPrefix(name, "Set")] = arg
+		return "SUCCESS"
+	case "SetKickIdleTime":
+		if _, err := strconv.Atoi(arg); err != nil {
+			return "FAIL"
+		}
+		f.settings["IdleTime"] = arg
+		return "SUCCESS"
+	case "SetAutoBalanceEnabled", "SetVoteKickEnabled":
+		if arg != "on" && arg != "off" {
+			return "FAIL"
+		}
+		f.settings[strings.TrimPrefix(name, "Set")] = arg
+		return "SUCCESS"
+	case "BanProfanity":
+		f.profanities = append(f.profanities, strings.Split(arg, ",")...)
+		return "SUCCESS"
+	case "UnbanProfanity":
+		f.profanities = slices.DeleteFunc(f.profanities, func(p string) bool {
+			return slices.Contains(strings.Split(arg, ","), p)
+		})
+		return "SUCCESS"
+	case "RotList":
+		return strings.Join(f.rotation, "\n")
+	case "RotAdd":
+		if !slices.Contains(f.maps, arg) {
+			return "FAIL"
+		}
+		f.rotation = append(f.rotation, arg)
+		return "SUCCESS"
+	case "RotDel":
+		i := slices.Index(f.rotation, arg)
+		if i == -1 || len(f.rotation) == 1 {
+			return "FAIL"
+		}
+		f.rotation = slices.Delete(f.rotation, i, i+1)
+		return "SUCCESS"
+	case "Map":
+		if !slices.Contains(f.maps, arg) {
+			return "FAIL"
+		}
+		f.settings["Map"] = arg
+		return "SUCCESS"
+	case "Say", "Broadcast":
+		f.settings[name] = arg
+		return "SUCCESS"
+	}
+	return "FAIL"
+}
+
+func list(entries []string) string {
+	return strconv.Itoa(len(entries)) + "\t" + strings.Join(entries, "\t") + "\t"
+}
+
+func xor(b, key []byte) []byte {
+	res := make([]byte, len(b))
+	for i := range b {
+		res[i] = b[i] ^ key[i%len(key)]
+	}
+	return res
+}
@@ -0,0 +1,89 @@
+package rcon
+
+import (
+	"context"
+	"github.com/floriansw/go-crcon"
+	"strings"
+)
+
+// environments are the light and weather conditions of maps, as used in the IDs of the maps.
+var environments = []string{"night", "dusk", "dawn", "morning", "overcast", "rain"}
+
+// Maps returns all maps the server can play. The server only reports the IDs, the game mode and environment are
+// derived from them.
+func (c *Client) Maps(ctx context.Context) (maps []crcon.Map, err error) {
+	err = c.session(ctx, func(rc *conn) error {
+		ids, err := rc.list(ctx, "Get MapsForRotation")
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			maps = append(maps, mapFromId(id))
+		}
+		return nil
+	})
+	return
+}
+
+func mapFromId(id string) crcon.Map {
+	m := crcon.Map{Id: id, GameMode: string(crcon.GameModeWarfare), Environment: "day"}
+	lower := strings.ToLower(id)
+	if strings.Contains(lower, "offensive") {
+		m.GameMode = string(crcon.GameModeOffensive)
+	} else if strings.Contains(lower, "skirmish") {
+		m.GameMode = string(crcon.GameModeSkirmish)
+	}
+	for _, e := range environments {
+		if strings.Contains(lower, e) {
+			m.Environment = e
+			break
+		}
+	}
+	// the IDs come from the server, an ID without a name prefix is used as the name
+	m.Name = id
+	if name, _, _ := strings.Cut(id, "_"); name != "" {
+		m.Name = strings.ToUpper(name[:1]) + name[1:]
+	}
+	return m
+}
+
+func (c *Client) SwitchMap(ctx context.Context, id string) error {
+	return c.exec(ctx, "Map "+id)
+}
+
+func (c *Client) MapRotation(ctx context.Context) (rotation crcon.MapRotation, err error) {
+	err = c.session(ctx, func(rc *conn) error {
+		res, err := rc.command(ctx, "RotList", nil)
+		if err != nil {
+			return err
+		}
+		for _, id := range strings.Fields(res) {
+			rotation = append(rotation, mapFromId(id))
+		}
+		return nil
+	})
+	return
+}
+
+// SetMapRotation replaces the map rotation. The server does not allow an empty rotation, hence the new maps are added
+// before the previous ones are removed.
+func (c *Client) SetMapRotation(ctx context.Context, ids []string) error {
+	return c.session(ctx, func(rc *conn) error {
+		res, err := rc.command(ctx, "RotList", nil)
+		if err != nil {
+			return err
+		}
+		current := strings.Fields(res)
+		for _, id := range ids {
+			if err := rc.exec(ctx, "RotAdd "+id); err != nil {
+				return err
+			}
+		}
+		for _, id := range current {
+			if err := rc.exec(ctx, "RotDel "+id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,13 @@
+package rcon_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestRCon(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "RCON Suite")
+}
@@ -0,0 +1,133 @@
+package internal
+
+import (
+	"cmp"
+	"github.com/floriansw/hll-discord-server-watcher/resources"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// cachedRepository keeps an in-memory index of the summaries of all entities in the wrapped storage. The index is
+// built on first use and kept up to date on Save and Delete.
+type cachedRepository[T resources.Summarizable] struct {
+	storage Storage[T]
+
+	mu    sync.RWMutex
+	index map[string]resources.Summary
+}
+
+func NewCachedRepository[T resources.Summarizable](s Storage[T]) *cachedRepository[T] {
+	return &cachedRepository[T]{storage: s}
+}
+
+func (r *cachedRepository[T]) Find(id string) (*T, error) {
+	v, err := resources.ParseId(id)
+	if err != nil {
+		return nil, err
+	}
+	return r.storage.Find(v)
+}
+
+func (r *cachedRepository[T]) Save(entity T) error {
+	if err := r.storage.Save(entity); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.index != nil {
+		r.index[entity.Id()] = entity.Summary()
+	}
+	return nil
+}
+
+func (r *cachedRepository[T]) Delete(id string) error {
+	v, err := resources.ParseId(id)
+	if err != nil {
+		return err
+	}
+	if err := r.storage.Delete(v); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.index != nil {
+		delete(r.index, id)
+	}
+	return nil
+}
+
+func (r *cachedRepository[T]) List() (ids []string, err error) {
+	summaries, err := r.summaries()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range summaries {
+		ids = append(ids, s.Id)
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+func (r *cachedRepository[T]) ListSummaries() ([]resources.Summary, error) {
+	return r.Search("")
+}
+
+func (r *cachedRepository[T]) Search(query string) (res []resources.Summary, err error) {
+	summaries, err := r.summaries()
+	if err != nil {
+		return nil, err
+	}
+	query = strings.ToLower(query)
+	for _, s := range summaries {
+		if strings.Contains(strings.ToLower(s.Name), query) || strings.Contains(strings.ToLower(s.Id), query) {
+			res = append(res, s)
+		}
+	}
+	slices.SortFunc(res, func(a, b resources.Summary) int {
+		if c := cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Id, b.Id)
+	})
+	return res, nil
+}
+
+func (r *cachedRepository[T]) Invalidate() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.index = nil
+}
+
+// summaries returns a copy of all summaries in the index, the index is built when it does not exist.
+func (r *cachedRepository[T]) summaries() ([]resources.Summary, error) {
+	r.mu.RLock()
+	if r.index != nil {
+		defer r.mu.RUnlock()
+		return slices.Collect(maps.Values(r.index)), nil
+	}
+	r.mu.RUnlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.index != nil {
+		return slices.Collect(maps.Values(r.index)), nil
+	}
+	ids, err := r.storage.List()
+	if err != nil {
+		return nil, err
+	}
+	idx := map[string]resources.Summary{}
+	for _, id := range ids {
+		e, err := r.storage.Find(id)
+		if err != nil {
+			return nil, err
+		}
+		if e != nil {
+			idx[id.String()] = (*e).Summary()
+		}
+	}
+	r.index = idx
+	return slices.Collect(maps.Values(idx)), nil
+}
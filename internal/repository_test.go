@@ -0,0 +1,65 @@
+package internal_test
+
+import (
+	"errors"
+	"github.com/floriansw/hll-discord-server-watcher/internal"
+	"github.com/floriansw/hll-discord-server-watcher/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"os"
+	"path"
+)
+
+var _ = Describe("CachedRepository", func() {
+	var (
+		dir  string
+		repo internal.Repository[resources.Template]
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp(os.TempDir(), "repository")
+		Expect(err).ToNot(HaveOccurred())
+		repo = internal.NewCachedRepository(resources.NewTemplates(dir))
+		Expect(repo.Save(resources.Template{TemplateId: "b", Name: "Seeding"})).ToNot(HaveOccurred())
+		Expect(repo.Save(resources.Template{TemplateId: "a", Name: "Event"})).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).ToNot(HaveOccurred())
+	})
+
+	It("lists summaries sorted by name", func() {
+		Expect(repo.ListSummaries()).To(Equal([]resources.Summary{{Id: "a", Name: "Event"}, {Id: "b", Name: "Seeding"}}))
+	})
+
+	It("filters summaries by name", func() {
+		Expect(repo.Search("seed")).To(Equal([]resources.Summary{{Id: "b", Name: "Seeding"}}))
+	})
+
+	It("keeps the index up to date on save and delete", func() {
+		_, err := repo.ListSummaries()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(repo.Save(resources.Template{TemplateId: "a", Name: "Warfare Event"})).ToNot(HaveOccurred())
+		Expect(repo.Delete("b")).ToNot(HaveOccurred())
+
+		Expect(repo.ListSummaries()).To(Equal([]resources.Summary{{Id: "a", Name: "Warfare Event"}}))
+	})
+
+	It("rejects IDs which can not be parsed", func() {
+		var ide *resources.InvalidIdError
+		_, err := repo.Find("../a")
+		Expect(errors.As(err, &ide)).To(BeTrue())
+		Expect(errors.As(repo.Delete("../a"), &ide)).To(BeTrue())
+	})
+
+	It("reloads the index after invalidation", func() {
+		_, err := repo.ListSummaries()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.WriteFile(path.Join(dir, "c"), []byte(`{"id":"c","name":"Added externally"}`), 0644)).ToNot(HaveOccurred())
+		Expect(repo.List()).To(ConsistOf("a", "b"))
+
+		repo.Invalidate()
+		Expect(repo.List()).To(ConsistOf("a", "b", "c"))
+	})
+})
@@ -0,0 +1,104 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrCircuitOpen = errors.New("circuit open after repeated failures")
+)
+
+// Breaker stops calls to a backend after consecutive failures for a cooldown, so that a broken server does not block
+// every interaction until it times out. After the cooldown one call is let through, which closes the circuit again on
+// success. Other calls are rejected until its outcome is recorded.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	// probing is set while the call let through after the cooldown is running.
+	probing bool
+}
+
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// Allow returns ErrCircuitOpen while the circuit is open. A call which is allowed must record its outcome.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.now().Before(b.openUntil) || b.probing {
+		return ErrCircuitOpen
+	}
+	if b.failures >= b.threshold {
+		b.probing = true
+	}
+	return nil
+}
+
+// Record counts failures, which indicate an unavailable backend. Errors like rejected credentials do not open the
+// circuit, as they are answered quickly.
+func (b *Breaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if err == nil || !Retryable(err) {
+		if err == nil {
+			b.failures = 0
+		}
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = b.now().Add(b.cooldown)
+	}
+}
+
+// Call runs f with the policy when the circuit is closed and records the outcome.
+func (b *Breaker) Call(ctx context.Context, p Policy, f func(ctx context.Context) error) error {
+	if err := b.Allow(); err != nil {
+		return err
+	}
+	err := p.Do(ctx, f)
+	b.Record(err)
+	return err
+}
+
+// Breakers holds one breaker per key, e.g. per server and backend.
+type Breakers struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+}
+
+func NewBreakers(threshold int, cooldown time.Duration) *Breakers {
+	return &Breakers{
+		threshold: threshold,
+		cooldown:  cooldown,
+		breakers:  map[string]*Breaker{},
+	}
+}
+
+func (b *Breakers) Get(key string) *Breaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	br, ok := b.breakers[key]
+	if !ok {
+		br = NewBreaker(b.threshold, b.cooldown)
+		b.breakers[key] = br
+	}
+	return br
+}
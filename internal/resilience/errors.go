@@ -0,0 +1,101 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"github.com/floriansw/go-crcon"
+	"github.com/floriansw/hll-discord-server-watcher/internal/hosting"
+	"github.com/floriansw/hll-discord-server-watcher/internal/rcon"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Category groups errors of backend calls by their cause, independent of the backend.
+type Category string
+
+const (
+	CategoryTimeout     = Category("timeout")
+	CategoryAuth        = Category("auth")
+	CategoryForbidden   = Category("forbidden")
+	CategoryServerError = Category("server-error")
+	CategoryNetwork     = Category("network")
+	CategoryUnavailable = Category("unavailable")
+	CategoryUnknown     = Category("unknown")
+)
+
+// statusCodePattern extracts the HTTP status code from errors of the backend clients, which only report it as text.
+var statusCodePattern = regexp.MustCompile(`(?:status code:?|status|got) (\d{3})\b`)
+
+var messages = map[Category]string{
+	CategoryTimeout:     "The server did not answer in time. It might be overloaded or offline, please try again later.",
+	CategoryAuth:        "The credentials were rejected. Please check them with the credentials command.",
+	CategoryForbidden:   "The credentials are valid, but lack the permission for this action. Please check the permissions of the API key or account.",
+	CategoryServerError: "The server answered with an error. It might be restarting, please try again in a few minutes.",
+	CategoryNetwork:     "The server could not be reached. Please check the URL and whether the server is online.",
+	CategoryUnavailable: "Requests to this server are paused after repeated failures. Please try again in a minute.",
+	CategoryUnknown:     "An unexpected error occurred.",
+}
+
+func Categorize(err error) Category {
+	if err == nil {
+		return ""
+	}
+	if errors.Is(err, ErrCircuitOpen) {
+		return CategoryUnavailable
+	}
+	var ne net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &ne) && ne.Timeout()) {
+		return CategoryTimeout
+	}
+	if errors.Is(err, crcon.ErrForbidden) {
+		return CategoryForbidden
+	}
+	if errors.Is(err, hosting.ErrUnauthorized) || errors.Is(err, rcon.ErrLoginFailed) || strings.Contains(err.Error(), "invalid username or password") {
+		return CategoryAuth
+	}
+	if m := statusCodePattern.FindStringSubmatch(err.Error()); m != nil {
+		code, _ := strconv.Atoi(m[1])
+		switch {
+		case code == 401:
+			return CategoryAuth
+		case code == 403:
+			return CategoryForbidden
+		case code == 429 || code >= 500:
+			return CategoryServerError
+		}
+	}
+	var oe *net.OpError
+	var de *net.DNSError
+	if errors.As(err, &oe) || errors.As(err, &de) {
+		return CategoryNetwork
+	}
+	return CategoryUnknown
+}
+
+// Retryable returns true for errors which might not occur again when the call is repeated.
+func Retryable(err error) bool {
+	switch Categorize(err) {
+	case CategoryTimeout, CategoryServerError, CategoryNetwork:
+		return true
+	default:
+		return false
+	}
+}
+
+// Explain returns the plain language explanation of the category of the error.
+func Explain(err error) string {
+	if err == nil {
+		return ""
+	}
+	return messages[Categorize(err)]
+}
+
+// Describe explains the error in plain language for users, followed by the technical error.
+func Describe(err error) string {
+	if err == nil {
+		return ""
+	}
+	return Explain(err) + "\n```\n" + err.Error() + "\n```"
+}
@@ -0,0 +1,13 @@
+package resilience_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestResilience(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Resilience Suite")
+}
@@ -0,0 +1,109 @@
+package resilience_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/floriansw/go-crcon"
+	"github.com/floriansw/hll-discord-server-watcher/internal/resilience"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net"
+	"time"
+)
+
+var errServer = errors.New("unexpected status code: 502")
+
+var _ = Describe("Resilience", func() {
+	Describe("Categorize", func() {
+		It("categorises errors of the backend clients", func() {
+			Expect(resilience.Categorize(context.DeadlineExceeded)).To(Equal(resilience.CategoryTimeout))
+			Expect(resilience.Categorize(crcon.ErrForbidden)).To(Equal(resilience.CategoryForbidden))
+			Expect(resilience.Categorize(errors.New("unexpected status code: 401"))).To(Equal(resilience.CategoryAuth))
+			Expect(resilience.Categorize(errors.New("invalid username or password"))).To(Equal(resilience.CategoryAuth))
+			Expect(resilience.Categorize(fmt.Errorf("updating: %w", errServer))).To(Equal(resilience.CategoryServerError))
+			Expect(resilience.Categorize(&net.OpError{Op: "dial", Err: errors.New("connection refused")})).To(Equal(resilience.CategoryNetwork))
+			Expect(resilience.Categorize(resilience.ErrCircuitOpen)).To(Equal(resilience.CategoryUnavailable))
+			Expect(resilience.Categorize(errors.New("something"))).To(Equal(resilience.CategoryUnknown))
+		})
+	})
+
+	Describe("Policy", func() {
+		p := resilience.Policy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+
+		It("retries retryable errors", func() {
+			calls := 0
+			err := p.Do(context.Background(), func(ctx context.Context) error {
+				calls++
+				if calls < 3 {
+					return errServer
+				}
+				return nil
+			})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calls).To(Equal(3))
+		})
+
+		It("does not retry other errors", func() {
+			calls := 0
+			err := p.Do(context.Background(), func(ctx context.Context) error {
+				calls++
+				return crcon.ErrForbidden
+			})
+
+			Expect(err).To(MatchError(crcon.ErrForbidden))
+			Expect(calls).To(Equal(1))
+		})
+
+		It("stops after the last attempt", func() {
+			calls := 0
+			err := p.Do(context.Background(), func(ctx context.Context) error {
+				calls++
+				return errServer
+			})
+
+			Expect(err).To(MatchError(errServer))
+			Expect(calls).To(Equal(3))
+		})
+	})
+
+	Describe("Breaker", func() {
+		It("opens after consecutive failures and closes after the cooldown", func() {
+			b := resilience.NewBreaker(2, 20*time.Millisecond)
+			fail := func(ctx context.Context) error { return errServer }
+
+			Expect(b.Call(context.Background(), resilience.NoRetry, fail)).To(MatchError(errServer))
+			Expect(b.Call(context.Background(), resilience.NoRetry, fail)).To(MatchError(errServer))
+			Expect(b.Call(context.Background(), resilience.NoRetry, fail)).To(MatchError(resilience.ErrCircuitOpen))
+
+			time.Sleep(30 * time.Millisecond)
+			Expect(b.Call(context.Background(), resilience.NoRetry, func(ctx context.Context) error { return nil })).ToNot(HaveOccurred())
+			Expect(b.Allow()).ToNot(HaveOccurred())
+		})
+
+		It("lets one call through after the cooldown", func() {
+			b := resilience.NewBreaker(1, 20*time.Millisecond)
+			b.Record(errServer)
+			time.Sleep(30 * time.Millisecond)
+
+			Expect(b.Allow()).ToNot(HaveOccurred())
+			Expect(b.Allow()).To(MatchError(resilience.ErrCircuitOpen))
+			b.Record(errServer)
+			Expect(b.Allow()).To(MatchError(resilience.ErrCircuitOpen))
+
+			time.Sleep(30 * time.Millisecond)
+			Expect(b.Allow()).ToNot(HaveOccurred())
+			b.Record(nil)
+			Expect(b.Allow()).ToNot(HaveOccurred())
+			Expect(b.Allow()).ToNot(HaveOccurred())
+		})
+
+		It("ignores errors which are not caused by an unavailable backend", func() {
+			b := resilience.NewBreaker(1, time.Minute)
+			b.Record(crcon.ErrForbidden)
+
+			Expect(b.Allow()).ToNot(HaveOccurred())
+		})
+	})
+})
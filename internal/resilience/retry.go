@@ -0,0 +1,51 @@
+package resilience
+
+import (
+	"context"
+	"time"
+)
+
+// Policy defines how often and how fast a failed call is repeated. Only idempotent calls may be retried.
+type Policy struct {
+	Attempts       int
+	BaseDelay      time.Duration
+	MaxDelay       time.Duration
+	AttemptTimeout time.Duration
+}
+
+var (
+	// DefaultPolicy is used for reads and writes, which set a value and can therefore be repeated safely.
+	DefaultPolicy = Policy{Attempts: 3, BaseDelay: 500 * time.Millisecond, MaxDelay: 5 * time.Second, AttemptTimeout: 15 * time.Second}
+	// NoRetry is used for actions, which must not be executed twice, like restarting a server.
+	NoRetry = Policy{Attempts: 1, AttemptTimeout: 30 * time.Second}
+)
+
+// Do calls f until it succeeds, fails with an error which is not retryable, the attempts are exhausted or ctx is done.
+// The delay between attempts doubles with every attempt.
+func (p Policy) Do(ctx context.Context, f func(ctx context.Context) error) error {
+	delay := p.BaseDelay
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = p.attempt(ctx, f)
+		if err == nil || attempt >= p.Attempts || !Retryable(err) || ctx.Err() != nil {
+			return err
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+		delay = min(delay*2, p.MaxDelay)
+	}
+}
+
+func (p Policy) attempt(ctx context.Context, f func(ctx context.Context) error) error {
+	if p.AttemptTimeout <= 0 {
+		return f(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.AttemptTimeout)
+	defer cancel()
+	return f(ctx)
+}
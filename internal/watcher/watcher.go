@@ -0,0 +1,185 @@
+package watcher
+
+import (
+	"errors"
+	"fmt"
+	"github.com/floriansw/hll-discord-server-watcher/internal"
+	"github.com/floriansw/hll-discord-server-watcher/resources"
+	"github.com/fsnotify/fsnotify"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// debounce is the time to wait for further file system events before changes are processed. Scripts usually touch
+// many files at once, which should result in one reload only.
+const debounce = 500 * time.Millisecond
+
+type Kind string
+
+const (
+	KindServer   = Kind("server")
+	KindTemplate = Kind("template")
+)
+
+type Change struct {
+	Kind Kind
+	Id   string
+	// Err is set when the changed file can not be read by the bot.
+	Err error
+}
+
+type Listener func(changes []Change)
+
+// Store is a storage which remembers what it wrote, to tell the changes of the bot apart from changes made outside
+// of it.
+type Store[T resources.Identifiable] interface {
+	internal.Storage[T]
+	// Written is true when the stored entity is the one the store saved or deleted last.
+	Written(id resources.Id) bool
+}
+
+// Watcher observes the servers and templates directories for changes made outside the bot, validates the changed
+// files and notifies registered listeners about them. Files saved or deleted by the bot itself are ignored.
+type Watcher struct {
+	logger    *slog.Logger
+	servers   Store[resources.Server]
+	templates Store[resources.Template]
+	dirs      map[string]Kind
+
+	mu        sync.Mutex
+	listeners []Listener
+}
+
+func New(l *slog.Logger, serversDir string, servers Store[resources.Server], templatesDir string, templates Store[resources.Template]) *Watcher {
+	return &Watcher{
+		logger:    l,
+		servers:   servers,
+		templates: templates,
+		dirs: map[string]Kind{
+			filepath.Clean(serversDir):   KindServer,
+			filepath.Clean(templatesDir): KindTemplate,
+		},
+	}
+}
+
+// OnChange registers a listener which is called with all changes after they were validated. Changes of invalid files
+// are passed with their Err set, e.g. to drop cached data of them.
+func (w *Watcher) OnChange(l Listener) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.listeners = append(w.listeners, l)
+}
+
+// Watch blocks and processes file system events until stop is closed.
+func (w *Watcher) Watch(stop <-chan struct{}) error {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer fw.Close()
+	for dir := range w.dirs {
+		if err := fw.Add(dir); err != nil {
+			return err
+		}
+	}
+
+	pending := map[Change]struct{}{}
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case err, ok := <-fw.Errors:
+			if !ok {
+				return nil
+			}
+			w.logger.Error("watch-resources", "error", err)
+		case e, ok := <-fw.Events:
+			if !ok {
+				return nil
+			}
+			if !e.Has(fsnotify.Create) && !e.Has(fsnotify.Write) && !e.Has(fsnotify.Remove) && !e.Has(fsnotify.Rename) {
+				continue
+			}
+			kind, ok := w.dirs[filepath.Dir(e.Name)]
+			if !ok {
+				continue
+			}
+			pending[Change{Kind: kind, Id: filepath.Base(e.Name)}] = struct{}{}
+			timer.Reset(debounce)
+		case <-timer.C:
+			var changes []Change
+			for c := range pending {
+				if w.written(c) {
+					continue
+				}
+				if c.Err = w.validate(c); c.Err != nil {
+					w.logger.Error("invalid-resource", "kind", c.Kind, "id", c.Id, "error", c.Err)
+				}
+				changes = append(changes, c)
+			}
+			pending = map[Change]struct{}{}
+			if len(changes) != 0 {
+				w.logger.Info("resources-changed", "count", len(changes))
+				w.notify(changes)
+			}
+		}
+	}
+}
+
+func (w *Watcher) notify(changes []Change) {
+	w.mu.Lock()
+	listeners := w.listeners
+	w.mu.Unlock()
+	for _, l := range listeners {
+		l(changes)
+	}
+}
+
+// written is true when the file was last saved or deleted by the bot itself.
+func (w *Watcher) written(c Change) bool {
+	id, err := resources.ParseId(c.Id)
+	if err != nil {
+		return false
+	}
+	switch c.Kind {
+	case KindServer:
+		return w.servers.Written(id)
+	case KindTemplate:
+		return w.templates.Written(id)
+	}
+	return false
+}
+
+// validate checks that a changed file can be read by the bot. A deleted file is always valid.
+func (w *Watcher) validate(c Change) error {
+	id, err := resources.ParseId(c.Id)
+	if err != nil {
+		return err
+	}
+	switch c.Kind {
+	case KindServer:
+		s, err := w.servers.Find(id)
+		if err != nil || s == nil {
+			return err
+		}
+		if s.Id() != c.Id {
+			return fmt.Errorf("server ID %s does not match the file name", s.Id())
+		}
+	case KindTemplate:
+		t, err := w.templates.Find(id)
+		if err != nil || t == nil {
+			return err
+		}
+		if t.Id() != c.Id {
+			return fmt.Errorf("template ID %s does not match the file name", t.Id())
+		}
+		return t.Validate()
+	default:
+		return errors.New("unknown resource kind")
+	}
+	return nil
+}
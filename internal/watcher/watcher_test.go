@@ -0,0 +1,108 @@
+package watcher_test
+
+import (
+	"github.com/floriansw/hll-discord-server-watcher/internal"
+	"github.com/floriansw/hll-discord-server-watcher/internal/watcher"
+	"github.com/floriansw/hll-discord-server-watcher/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"log/slog"
+	"os"
+	"path"
+	"sync"
+	"time"
+)
+
+var _ = Describe("Watcher", func() {
+	var (
+		dir       string
+		stop      chan struct{}
+		mu        sync.Mutex
+		changes   []watcher.Change
+		templates internal.Storage[resources.Template]
+	)
+
+	received := func() []watcher.Change {
+		mu.Lock()
+		defer mu.Unlock()
+		return changes
+	}
+	// write writes the files until the watcher reported a change of each of them, as it takes some time until the
+	// directories are watched. The polling interval is longer than the debounce time of the watcher.
+	write := func(files map[string]string) func() bool {
+		return func() bool {
+			seen := map[string]bool{}
+			for _, c := range received() {
+				seen[string(c.Kind)+"s/"+c.Id] = true
+			}
+			done := true
+			for name, content := range files {
+				if !seen[name] {
+					done = false
+					Expect(os.WriteFile(path.Join(dir, name), []byte(content), 0644)).ToNot(HaveOccurred())
+				}
+			}
+			return done
+		}
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp(os.TempDir(), "watcher")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.MkdirAll(path.Join(dir, "servers"), 0755)).ToNot(HaveOccurred())
+		Expect(os.MkdirAll(path.Join(dir, "templates"), 0755)).ToNot(HaveOccurred())
+		changes = nil
+		stop = make(chan struct{})
+
+		l := slog.New(slog.NewTextHandler(os.Stdout, nil))
+		tpls := resources.NewTemplates(path.Join(dir, "templates"))
+		templates = tpls
+		w := watcher.New(
+			l,
+			path.Join(dir, "servers"), resources.NewServers(path.Join(dir, "servers")),
+			path.Join(dir, "templates"), tpls,
+		)
+		w.OnChange(func(c []watcher.Change) {
+			mu.Lock()
+			defer mu.Unlock()
+			changes = append(changes, c...)
+		})
+		go func() {
+			defer GinkgoRecover()
+			Expect(w.Watch(stop)).ToNot(HaveOccurred())
+		}()
+	})
+
+	AfterEach(func() {
+		close(stop)
+		Expect(os.RemoveAll(dir)).ToNot(HaveOccurred())
+	})
+
+	It("notifies about changes with the validation result", func() {
+		Eventually(write(map[string]string{
+			"templates/valid":   `{"id":"valid","name":"Valid"}`,
+			"templates/invalid": `{"id":"invalid"}`,
+			"servers/broken":    `{`,
+		}), 5*time.Second, 700*time.Millisecond).Should(BeTrue())
+
+		var invalid []string
+		for _, c := range received() {
+			if c.Err != nil {
+				invalid = append(invalid, c.Id)
+			} else {
+				Expect(c).To(Equal(watcher.Change{Kind: watcher.KindTemplate, Id: "valid"}))
+			}
+		}
+		Expect(invalid).To(ConsistOf("invalid", "broken"))
+	})
+
+	It("ignores files saved by the bot", func() {
+		Eventually(func() bool {
+			Expect(templates.Save(resources.Template{TemplateId: "own", Name: "Own"})).ToNot(HaveOccurred())
+			return write(map[string]string{"templates/external": `{"id":"external","name":"External"}`})()
+		}, 5*time.Second, 700*time.Millisecond).Should(BeTrue())
+
+		Consistently(received, time.Second).Should(HaveEach(watcher.Change{Kind: watcher.KindTemplate, Id: "external"}))
+	})
+})
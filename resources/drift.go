@@ -0,0 +1,59 @@
+package resources
+
+import (
+	"slices"
+	"time"
+)
+
+// AppliedTemplate records the values a template set on a server, to detect settings changed outside of the bot.
+type AppliedTemplate struct {
+	TemplateId string    `json:"template_id"`
+	Version    int       `json:"version"`
+	AppliedAt  time.Time `json:"applied_at"`
+	// Values are the settings as they were applied. ServerNameTemplate holds the server name which was set and
+	// MapRotation the rotation after filtering it by the game mode.
+	Values Template `json:"values"`
+	// Unapplied are the settings which could not be applied, they are not compared with the live settings.
+	Unapplied []string `json:"unapplied,omitempty"`
+	// ReportedDrift are the differences which were reported last, to only report a drift once.
+	ReportedDrift []Difference `json:"reported_drift,omitempty"`
+}
+
+// Drift compares the applied values with the live settings of the server. The unapplied and the skipped settings are
+// not compared.
+func (a AppliedTemplate) Drift(live Template, skip ...string) []Difference {
+	return a.Values.Differences(live, append(slices.Clone(a.Unapplied), skip...)...)
+}
+
+// Difference is a setting of a server which does not have the value the applied template set.
+type Difference struct {
+	Setting  string `json:"setting"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// Differences compares the settings of the template with the live settings of a server. Settings the template leaves
+// unchanged and the skipped settings, e.g. ones which could not be read from the server, are not compared.
+func (t Template) Differences(live Template, skip ...string) (d []Difference) {
+	for _, s := range settings {
+		expected, ok := s.value(t)
+		if !ok || slices.Contains(skip, s.name) {
+			continue
+		}
+		if actual, _ := s.value(live); actual != expected {
+			d = append(d, Difference{Setting: s.name, Expected: expected, Actual: actual})
+		}
+	}
+	return
+}
+
+// Adopt returns a copy of the template with the given settings taken from the live settings of a server. Adopting
+// the map rotation removes the game mode and starting map, as the live rotation has neither.
+func (t Template) Adopt(live Template, names []string) Template {
+	for _, s := range settings {
+		if slices.Contains(names, s.name) {
+			s.adopt(&t, live)
+		}
+	}
+	return t
+}
@@ -0,0 +1,79 @@
+package resources_test
+
+import (
+	"github.com/floriansw/hll-discord-server-watcher/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Drift", func() {
+	zero, five, six, ten := 0, 5, 6, 10
+	applied := resources.Template{
+		Name:               "Event",
+		TeamSwitchCooldown: &ten,
+		WelcomeMessage:     "Welcome",
+		ProfanityFilter:    []string{"b", "a"},
+		QueueLength:        &six,
+		MapRotation:        []resources.Map{{Id: "foy_warfare"}, {Id: "kursk_warfare"}},
+		StartingMap:        "kursk_warfare",
+	}
+
+	Describe("Differences", func() {
+		It("reports no differences for matching settings", func() {
+			live := applied
+			live.ProfanityFilter = []string{"a", "b"}
+			Expect(applied.Differences(live)).To(BeEmpty())
+		})
+
+		It("does not compare settings which were not applied", func() {
+			live := applied
+			live.TeamSwitchCooldown = &zero
+			live.QueueLength = &five
+			a := resources.AppliedTemplate{Values: applied, Unapplied: []string{resources.SettingTeamSwitchCooldown}}
+			Expect(a.Drift(live, resources.SettingQueueLength)).To(BeEmpty())
+		})
+
+		It("reports changed settings with expected and actual values", func() {
+			live := applied
+			live.TeamSwitchCooldown = &zero
+			live.QueueLength = &five
+			Expect(applied.Differences(live)).To(Equal([]resources.Difference{
+				{Setting: resources.SettingTeamSwitchCooldown, Expected: "10", Actual: "0"},
+				{Setting: resources.SettingQueueLength, Expected: "6", Actual: "5"},
+			}))
+		})
+
+		It("ignores settings the template leaves unchanged", func() {
+			live := applied
+			live.VipSlots = &five
+			live.ServerNameTemplate = "Renamed"
+			Expect(applied.Differences(live)).To(BeEmpty())
+		})
+
+		It("ignores skipped settings", func() {
+			live := applied
+			live.WelcomeMessage = ""
+			Expect(applied.Differences(live, resources.SettingWelcomeMessage)).To(BeEmpty())
+		})
+	})
+
+	Describe("Adopt", func() {
+		It("takes only the given settings from the live values", func() {
+			live := applied
+			live.TeamSwitchCooldown = &zero
+			live.WelcomeMessage = "Changed"
+			t := applied.Adopt(live, []string{resources.SettingWelcomeMessage})
+			Expect(t.WelcomeMessage).To(Equal("Changed"))
+			Expect(t.TeamSwitchCooldown).To(Equal(&ten))
+		})
+
+		It("removes the starting map when adopting the rotation", func() {
+			live := applied
+			live.MapRotation = []resources.Map{{Id: "foy_warfare"}}
+			live.StartingMap = ""
+			t := applied.Adopt(live, []string{resources.SettingMapRotation})
+			Expect(t.MapRotation).To(HaveLen(1))
+			Expect(t.StartingMap).To(BeEmpty())
+		})
+	})
+})
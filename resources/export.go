@@ -0,0 +1,40 @@
+package resources
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// ExportTemplates encodes templates in the format of the template export. A list without templates is encoded as an
+// empty list, so that it can be imported again.
+func ExportTemplates(templates []Template) ([]byte, error) {
+	if templates == nil {
+		templates = []Template{}
+	}
+	return json.MarshalIndent(templates, "", "  ")
+}
+
+// ParseTemplates accepts the output of the template export, which is either a single template or a list of templates.
+// All templates are validated.
+func ParseTemplates(b []byte) ([]Template, error) {
+	b = bytes.TrimSpace(b)
+	var templates []Template
+	if bytes.HasPrefix(b, []byte("[")) {
+		if err := json.Unmarshal(b, &templates); err != nil {
+			return nil, err
+		}
+	} else {
+		var tpl Template
+		if err := json.Unmarshal(b, &tpl); err != nil {
+			return nil, err
+		}
+		templates = append(templates, tpl)
+	}
+	for _, tpl := range templates {
+		if err := tpl.Validate(); err != nil {
+			return nil, fmt.Errorf("template %s: %w", tpl.Name, err)
+		}
+	}
+	return templates, nil
+}
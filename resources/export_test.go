@@ -0,0 +1,32 @@
+package resources_test
+
+import (
+	"github.com/floriansw/hll-discord-server-watcher/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Export", func() {
+	It("imports exported templates again", func() {
+		zero := 0
+		templates := []resources.Template{{TemplateId: "event", Name: "Event", ParentId: "base", TeamSwitchCooldown: &zero}, {Name: "Seeding"}}
+		b, err := resources.ExportTemplates(templates)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(resources.ParseTemplates(b)).To(Equal(templates))
+	})
+
+	It("imports an export without templates", func() {
+		b, err := resources.ExportTemplates(nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(b)).To(Equal("[]"))
+
+		Expect(resources.ParseTemplates(b)).To(BeEmpty())
+	})
+
+	It("accepts a single template", func() {
+		Expect(resources.ParseTemplates([]byte(` {"name":"Event"}`))).To(Equal([]resources.Template{{Name: "Event"}}))
+		_, err := resources.ParseTemplates([]byte(`{"name":""}`))
+		Expect(err).To(HaveOccurred())
+	})
+})
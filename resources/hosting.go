@@ -0,0 +1,149 @@
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	numericIdPattern           = regexp.MustCompile(`^[0-9]{1,20}$`)
+	pterodactylServerIdPattern = regexp.MustCompile(`^[a-zA-Z0-9-]{1,64}$`)
+	environmentVariablePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+	apiPrefixPattern           = regexp.MustCompile(`^/?[a-zA-Z0-9_-]+(/[a-zA-Z0-9_-]+)*/?$`)
+)
+
+// HostingProvider is the service the game server is hosted at. It is used to read and change the server name and
+// password and to restart the server.
+type HostingProvider string
+
+const (
+	HostingTCAdmin     = HostingProvider("tcadmin")
+	HostingPterodactyl = HostingProvider("pterodactyl")
+)
+
+// TCAdminCredentials grant access to a service in a TCAdmin panel.
+type TCAdminCredentials struct {
+	// BaseUrl of the panel including the protocol, e.g. https://panel.example.com:8443/tcadmin. Credentials created
+	// before full URLs were supported only contain the host name.
+	BaseUrl   string `json:"base_url"`
+	ServiceId string `json:"service_id"`
+	Username  string `json:"username"`
+	Password  string `json:"password"`
+	// GameId, ModId and FileId identify the config file of the game server in the panel. The IDs of Hell Let Loose
+	// are used when empty.
+	GameId string `json:"game_id,omitempty"`
+	ModId  string `json:"mod_id,omitempty"`
+	FileId string `json:"file_id,omitempty"`
+
+	Health CredentialsHealth `json:"health"`
+}
+
+// Url returns the parsed base URL of the panel, host names without protocol are served with https.
+func (c TCAdminCredentials) Url() (*url.URL, error) {
+	raw := c.BaseUrl
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	return url.Parse(raw)
+}
+
+// PanelAddress is the base URL without protocol and trailing slash, as expected by the TCAdmin client.
+func (c TCAdminCredentials) PanelAddress() string {
+	u, err := c.Url()
+	if err != nil {
+		return c.BaseUrl
+	}
+	return u.Host + strings.TrimSuffix(u.Path, "/")
+}
+
+// SameTarget tells whether both credentials log in with the same user to the same panel, see
+// CRConCredentials.SameTarget.
+func (c TCAdminCredentials) SameTarget(o TCAdminCredentials) bool {
+	u, err := c.Url()
+	if err != nil {
+		return false
+	}
+	ou, err := o.Url()
+	if err != nil {
+		return false
+	}
+	return u.String() == ou.String() && c.Username == o.Username
+}
+
+func (c TCAdminCredentials) Validate() error {
+	u, err := c.Url()
+	if err != nil {
+		return fmt.Errorf("invalid base URL: %w", err)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return errors.New("the base URL must start with https://, TCAdmin panels are only supported with https")
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return errors.New("the base URL must not contain a query or fragment")
+	}
+	if !numericIdPattern.MatchString(c.ServiceId) {
+		return errors.New("the service ID must be a number")
+	}
+	if c.Username == "" || c.Password == "" {
+		return errors.New("username and password are required")
+	}
+	if c.GameId != "" || c.ModId != "" || c.FileId != "" {
+		for _, id := range []string{c.GameId, c.ModId, c.FileId} {
+			if !numericIdPattern.MatchString(id) {
+				return errors.New("game, mod and file IDs must all be set to numbers")
+			}
+		}
+	}
+	return nil
+}
+
+// ParseConfigIds splits the game, mod and file IDs of a config file separated by slashes, e.g. 1098726659/0/1. The
+// IDs are checked by Validate.
+func ParseConfigIds(s string) (gameId, modId, fileId string, err error) {
+	ids := strings.Split(s, "/")
+	if len(ids) != 3 {
+		return "", "", "", errors.New("the game, mod and file IDs must be separated by a slash, e.g. 1098726659/0/1")
+	}
+	return strings.TrimSpace(ids[0]), strings.TrimSpace(ids[1]), strings.TrimSpace(ids[2]), nil
+}
+
+// PterodactylCredentials grant access to a server through the client API of a Pterodactyl panel. The server name and
+// password are startup variables, their names depend on the egg used by the host.
+type PterodactylCredentials struct {
+	BaseUrl          string `json:"base_url"`
+	ServerId         string `json:"server_id"`
+	ApiKey           string `json:"api_key"`
+	NameVariable     string `json:"name_variable"`
+	PasswordVariable string `json:"password_variable"`
+
+	Health CredentialsHealth `json:"health"`
+}
+
+// SameTarget tells whether both credentials are used for the same server of the same panel, see
+// CRConCredentials.SameTarget.
+func (c PterodactylCredentials) SameTarget(o PterodactylCredentials) bool {
+	return c.BaseUrl == o.BaseUrl && c.ServerId == o.ServerId
+}
+
+func (c PterodactylCredentials) Validate() error {
+	u, err := url.Parse(c.BaseUrl)
+	if err != nil {
+		return fmt.Errorf("invalid panel URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("the panel URL must start with http:// or https://")
+	}
+	if !pterodactylServerIdPattern.MatchString(c.ServerId) {
+		return errors.New("the server ID must only contain letters, digits and dashes")
+	}
+	if c.ApiKey == "" {
+		return errors.New("the API key is required")
+	}
+	if !environmentVariablePattern.MatchString(c.NameVariable) || !environmentVariablePattern.MatchString(c.PasswordVariable) {
+		return errors.New("startup variables must only contain upper case letters, digits and underscores")
+	}
+	return nil
+}
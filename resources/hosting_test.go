@@ -0,0 +1,143 @@
+package resources_test
+
+import (
+	"github.com/floriansw/hll-discord-server-watcher/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Hosting", func() {
+	Describe("PterodactylCredentials", func() {
+		valid := resources.PterodactylCredentials{
+			BaseUrl:          "https://panel.example.com",
+			ServerId:         "1a2b3c4d",
+			ApiKey:           "ptlc_key",
+			NameVariable:     "SERVER_NAME",
+			PasswordVariable: "SERVER_PASSWORD",
+		}
+
+		It("accepts valid credentials", func() {
+			Expect(valid.Validate()).ToNot(HaveOccurred())
+		})
+
+		It("rejects a panel URL without protocol", func() {
+			c := valid
+			c.BaseUrl = "panel.example.com"
+			Expect(c.Validate()).To(HaveOccurred())
+		})
+
+		It("rejects an invalid server ID", func() {
+			c := valid
+			c.ServerId = "../1a2b"
+			Expect(c.Validate()).To(HaveOccurred())
+		})
+
+		It("rejects invalid startup variables", func() {
+			c := valid
+			c.NameVariable = "server name"
+			Expect(c.Validate()).To(HaveOccurred())
+		})
+
+		It("only has the same target for the same panel and server", func() {
+			c := valid
+			c.ApiKey = ""
+			c.NameVariable = "NAME"
+			Expect(c.SameTarget(valid)).To(BeTrue())
+			c.ServerId = "5e6f7a8b"
+			Expect(c.SameTarget(valid)).To(BeFalse())
+			c = valid
+			c.BaseUrl = "https://attacker.example.com"
+			Expect(c.SameTarget(valid)).To(BeFalse())
+		})
+	})
+
+	Describe("TCAdminCredentials", func() {
+		valid := resources.TCAdminCredentials{
+			BaseUrl:   "https://panel.example.com:8443/tcadmin/",
+			ServiceId: "1234",
+			Username:  "user",
+			Password:  "pass",
+		}
+
+		It("accepts valid credentials", func() {
+			Expect(valid.Validate()).ToNot(HaveOccurred())
+		})
+
+		It("strips the protocol for the panel address", func() {
+			Expect(valid.PanelAddress()).To(Equal("panel.example.com:8443/tcadmin"))
+		})
+
+		It("accepts host names without protocol", func() {
+			c := valid
+			c.BaseUrl = "qp.qonzer.com"
+			Expect(c.Validate()).ToNot(HaveOccurred())
+			Expect(c.PanelAddress()).To(Equal("qp.qonzer.com"))
+		})
+
+		It("rejects http", func() {
+			c := valid
+			c.BaseUrl = "http://panel.example.com"
+			Expect(c.Validate()).To(HaveOccurred())
+		})
+
+		It("rejects non-numeric IDs", func() {
+			c := valid
+			c.GameId = "hll"
+			Expect(c.Validate()).To(HaveOccurred())
+		})
+
+		It("requires all config IDs when one is set", func() {
+			c := valid
+			c.GameId, c.ModId, c.FileId = "1098726659", "0", "1"
+			Expect(c.Validate()).ToNot(HaveOccurred())
+			c.ModId = ""
+			Expect(c.Validate()).To(HaveOccurred())
+		})
+
+		It("parses exactly three config IDs", func() {
+			gameId, modId, fileId, err := resources.ParseConfigIds("1098726659 / 0 / 1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect([]string{gameId, modId, fileId}).To(Equal([]string{"1098726659", "0", "1"}))
+
+			_, _, _, err = resources.ParseConfigIds("1098726659/0/1/2")
+			Expect(err).To(HaveOccurred())
+			c := valid
+			c.GameId, c.ModId, c.FileId, err = resources.ParseConfigIds("1098726659//1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(c.Validate()).To(HaveOccurred())
+		})
+
+		It("only has the same target for the same panel and user", func() {
+			c := valid
+			c.Password = ""
+			c.ServiceId = "5678"
+			Expect(c.SameTarget(valid)).To(BeTrue())
+			c.BaseUrl = "https://attacker.example.com/tcadmin/"
+			Expect(c.SameTarget(valid)).To(BeFalse())
+			c = valid
+			c.Username = "other"
+			Expect(c.SameTarget(valid)).To(BeFalse())
+		})
+
+		It("has the same target for host names without protocol", func() {
+			c := valid
+			c.BaseUrl = "qp.qonzer.com"
+			o := valid
+			o.BaseUrl = "https://qp.qonzer.com"
+			Expect(c.SameTarget(o)).To(BeTrue())
+		})
+	})
+
+	Describe("Server", func() {
+		It("defaults to TCAdmin", func() {
+			s := resources.Server{TCAdminCredentials: &resources.TCAdminCredentials{}}
+			Expect(s.Hosting()).To(Equal(resources.HostingTCAdmin))
+			Expect(s.HostingConfigured()).To(BeTrue())
+		})
+
+		It("requires credentials of the selected provider", func() {
+			s := resources.Server{HostingProvider: resources.HostingPterodactyl, TCAdminCredentials: &resources.TCAdminCredentials{}}
+			Expect(s.HostingConfigured()).To(BeFalse())
+		})
+	})
+})
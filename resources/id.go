@@ -0,0 +1,35 @@
+package resources
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"regexp"
+)
+
+var slugPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]{0,63}$`)
+
+// Id is the identifier of a stored resource. It is used as the file name in the store, hence only UUIDs and slugs
+// (letters, digits, dashes and underscores) are valid. An Id can only be obtained from ParseId, the zero value is
+// rejected by the store.
+type Id struct {
+	v string
+}
+
+type InvalidIdError struct {
+	Id string
+}
+
+func (e *InvalidIdError) Error() string {
+	return fmt.Sprintf("invalid ID %q: only UUIDs and slugs are allowed", e.Id)
+}
+
+func ParseId(id string) (Id, error) {
+	if _, err := uuid.Parse(id); err == nil || slugPattern.MatchString(id) {
+		return Id{v: id}, nil
+	}
+	return Id{}, &InvalidIdError{Id: id}
+}
+
+func (i Id) String() string {
+	return i.v
+}
@@ -0,0 +1,92 @@
+package resources
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInheritanceCycle is returned when a template is its own ancestor.
+var ErrInheritanceCycle = errors.New("the parent templates form a cycle")
+
+// Chain returns the template followed by its parent, the parent of the parent and so on. find looks up a template by
+// its ID and returns nil when it does not exist.
+func (t Template) Chain(find func(id string) (*Template, error)) ([]Template, error) {
+	chain := []Template{t}
+	seen := map[string]bool{t.TemplateId: true}
+	for cur := t; cur.ParentId != ""; {
+		if seen[cur.ParentId] {
+			return nil, fmt.Errorf("%w: %s inherits from itself", ErrInheritanceCycle, t.Name)
+		}
+		seen[cur.ParentId] = true
+		p, err := find(cur.ParentId)
+		if err != nil {
+			return nil, err
+		}
+		if p == nil {
+			return nil, fmt.Errorf("the parent template %s of %s does not exist", cur.ParentId, cur.Name)
+		}
+		chain = append(chain, *p)
+		cur = *p
+	}
+	return chain, nil
+}
+
+// Descendants returns the IDs of the templates inheriting from the template with the given ID, directly or through
+// other parents, out of the summaries of all templates.
+func Descendants(summaries []Summary, id string) map[string]bool {
+	res := map[string]bool{}
+	for next := []string{id}; len(next) != 0; {
+		parent := next[0]
+		next = next[1:]
+		for _, s := range summaries {
+			if s.ParentId == parent && !res[s.Id] && s.Id != id {
+				res[s.Id] = true
+				next = append(next, s.Id)
+			}
+		}
+	}
+	return res
+}
+
+// Effective returns the template with every setting it leaves empty inherited from the closest ancestor setting it.
+// A child can not override a setting with an empty value, e.g. an empty welcome message. inherited maps the names
+// of the inherited settings to the name of the template they are inherited from.
+func (t Template) Effective(find func(id string) (*Template, error)) (res Template, inherited map[string]string, err error) {
+	chain, err := t.Chain(find)
+	if err != nil {
+		return t, nil, err
+	}
+	res, inherited = t, map[string]string{}
+	for _, parent := range chain[1:] {
+		for _, s := range settings {
+			if s.empty(res) && !s.empty(parent) {
+				s.adopt(&res, parent)
+				inherited[s.name] = parent.Name
+			}
+		}
+	}
+	return res, inherited, nil
+}
+
+// Overrides returns the names of the settings the template sets itself instead of inheriting them.
+func (t Template) Overrides() (names []string) {
+	for _, s := range settings {
+		if !s.empty(t) {
+			names = append(names, s.name)
+		}
+	}
+	return
+}
+
+// Override returns the template with the settings set in the overrides replacing its own, e.g. the overrides of a
+// server. overridden are the names of the replaced settings.
+func (t Template) Override(o Template) (res Template, overridden []string) {
+	res = t
+	for _, s := range settings {
+		if !s.empty(o) {
+			s.adopt(&res, o)
+			overridden = append(overridden, s.name)
+		}
+	}
+	return
+}
@@ -0,0 +1,120 @@
+package resources_test
+
+import (
+	"errors"
+	"github.com/floriansw/hll-discord-server-watcher/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Inheritance", func() {
+	var templates map[string]resources.Template
+	find := func(id string) (*resources.Template, error) {
+		if t, ok := templates[id]; ok {
+			return &t, nil
+		}
+		return nil, nil
+	}
+	const (
+		baseId  = "7a0c3d4e-0000-4000-8000-000000000001"
+		eventId = "7a0c3d4e-0000-4000-8000-000000000002"
+		nightId = "7a0c3d4e-0000-4000-8000-000000000003"
+	)
+	zero, five, fifteen := 0, 5, 15
+
+	BeforeEach(func() {
+		templates = map[string]resources.Template{
+			baseId: {
+				TemplateId:         baseId,
+				Name:               "Base",
+				WelcomeMessage:     "Welcome",
+				ProfanityFilter:    []string{"word"},
+				TeamSwitchCooldown: &fifteen,
+				MapRotation:        []resources.Map{{Id: "foy_warfare"}},
+				StartingMap:        "foy_warfare",
+			},
+			eventId: {TemplateId: eventId, Name: "Event", ParentId: baseId, TeamSwitchCooldown: &five},
+			nightId: {TemplateId: nightId, Name: "Night", ParentId: eventId, WelcomeMessage: "Good night", ProfanityFilter: []string{""}},
+		}
+	})
+
+	It("inherits empty settings from the closest ancestor", func() {
+		t, inherited, err := templates[nightId].Effective(find)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(t.Name).To(Equal("Night"))
+		Expect(t.WelcomeMessage).To(Equal("Good night"))
+		Expect(t.TeamSwitchCooldown).To(Equal(&five))
+		Expect(t.ProfanityFilter).To(Equal([]string{"word"}))
+		Expect(t.StartingMap).To(Equal("foy_warfare"))
+		Expect(inherited).To(Equal(map[string]string{
+			resources.SettingTeamSwitchCooldown: "Event",
+			resources.SettingProfanityFilter:    "Base",
+			resources.SettingMapRotation:        "Base",
+		}))
+	})
+
+	It("overrides a setting of the parent with 0", func() {
+		event := templates[eventId]
+		event.TeamSwitchCooldown = &zero
+		templates[eventId] = event
+		t, inherited, err := templates[nightId].Effective(find)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(t.TeamSwitchCooldown).To(Equal(&zero))
+		Expect(inherited).To(HaveKeyWithValue(resources.SettingTeamSwitchCooldown, "Event"))
+	})
+
+	It("detects cycles", func() {
+		base := templates[baseId]
+		base.ParentId = nightId
+		templates[baseId] = base
+		_, _, err := templates[nightId].Effective(find)
+		Expect(errors.Is(err, resources.ErrInheritanceCycle)).To(BeTrue())
+	})
+
+	It("fails for a missing parent", func() {
+		delete(templates, baseId)
+		_, _, err := templates[eventId].Effective(find)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("finds the descendants of a template", func() {
+		var summaries []resources.Summary
+		for _, t := range templates {
+			summaries = append(summaries, t.Summary())
+		}
+		Expect(resources.Descendants(summaries, baseId)).To(Equal(map[string]bool{eventId: true, nightId: true}))
+		Expect(resources.Descendants(summaries, eventId)).To(Equal(map[string]bool{nightId: true}))
+		Expect(resources.Descendants(summaries, nightId)).To(BeEmpty())
+	})
+
+	It("stops at cycles when finding descendants", func() {
+		base := templates[baseId]
+		base.ParentId = nightId
+		templates[baseId] = base
+		var summaries []resources.Summary
+		for _, t := range templates {
+			summaries = append(summaries, t.Summary())
+		}
+		Expect(resources.Descendants(summaries, eventId)).To(Equal(map[string]bool{nightId: true, baseId: true}))
+	})
+
+	It("rejects a template inheriting from itself", func() {
+		t := templates[baseId]
+		t.ParentId = baseId
+		Expect(t.Validate()).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Override", func() {
+	It("replaces the settings set in the overrides", func() {
+		queue, two, ten, zero := 3, 2, 10, 0
+		t := resources.Template{Name: "Public", WelcomeMessage: "Welcome", AutoBalanceThreshold: &two, TeamSwitchCooldown: &ten, QueueLength: &queue}
+		res, overridden := t.Override(resources.Template{WelcomeMessage: "Join discord.gg/example", AutoBalanceThreshold: &zero})
+		Expect(res.Name).To(Equal("Public"))
+		Expect(res.WelcomeMessage).To(Equal("Join discord.gg/example"))
+		Expect(res.AutoBalanceThreshold).To(Equal(&zero))
+		Expect(res.TeamSwitchCooldown).To(Equal(&ten))
+		Expect(res.QueueLength).To(Equal(&queue))
+		Expect(overridden).To(ConsistOf(resources.SettingWelcomeMessage, resources.SettingAutoBalanceThreshold))
+	})
+})
@@ -1,29 +1,176 @@
 package resources
 
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
 type Server struct {
 	ServerId string `json:"server_id"`
 	Name     string `json:"name"`
 
-	CRConCredentials   *CRConCredentials   `json:"crcon_credentials"`
-	TCAdminCredentials *TCAdminCredentials `json:"tcadmin_credentials"`
+	CRConCredentials *CRConCredentials `json:"crcon_credentials"`
+	RConCredentials  *RConCredentials  `json:"rcon_credentials"`
+
+	HostingProvider        HostingProvider         `json:"hosting_provider"`
+	TCAdminCredentials     *TCAdminCredentials     `json:"tcadmin_credentials"`
+	PterodactylCredentials *PterodactylCredentials `json:"pterodactyl_credentials"`
 
 	PendingUpdate *ServerUpdate `json:"pending_update"`
+	// Overrides are settings of this server which replace the ones of the applied template. Settings left empty are
+	// taken from the template.
+	Overrides *Template `json:"overrides,omitempty"`
+	// NameVariables are the values of custom placeholders in server name templates, e.g. {region}.
+	NameVariables map[string]string `json:"name_variables,omitempty"`
+	// AppliedTemplate is the template which was applied to the server last.
+	AppliedTemplate *AppliedTemplate `json:"applied_template,omitempty"`
 }
 
 func (s Server) Id() string {
 	return s.ServerId
 }
 
+func (s Server) Summary() Summary {
+	d := "Credentials missing"
+	if s.RConConfigured() && s.HostingConfigured() {
+		d = "Ready to be managed"
+	} else if s.RConConfigured() {
+		d = "Templates only, no hosting credentials"
+	}
+	res := Summary{
+		Id:          s.ServerId,
+		Name:        s.Name,
+		Manageable:  s.RConConfigured(),
+		Credentials: s.RConConfigured() || s.TCAdminCredentials != nil || s.PterodactylCredentials != nil,
+	}
+	if s.AppliedTemplate != nil {
+		d += ", running " + s.AppliedTemplate.Values.Name
+		res.TemplateId = s.AppliedTemplate.TemplateId
+	}
+	res.Description = d
+	return res
+}
+
+// Hosting returns the provider the game server is managed with. Servers created before the provider could be chosen
+// are hosted with TCAdmin.
+func (s Server) Hosting() HostingProvider {
+	if s.HostingProvider == "" {
+		return HostingTCAdmin
+	}
+	return s.HostingProvider
+}
+
+// RConConfigured is true when the game server can be managed, either through CRCon or directly through RCON.
+func (s Server) RConConfigured() bool {
+	return s.CRConCredentials != nil || s.RConCredentials != nil
+}
+
+// HostingConfigured is true when the credentials of the selected hosting provider are set.
+func (s Server) HostingConfigured() bool {
+	if s.Hosting() == HostingPterodactyl {
+		return s.PterodactylCredentials != nil
+	}
+	return s.TCAdminCredentials != nil
+}
+
 type CRConCredentials struct {
 	BaseUrl string `json:"base_url"`
 	ApiKey  string `json:"api_key"`
+	// ServerNumber selects one of the game servers of a CRCon installation managing several servers, InstanceUrl is
+	// the URL of that server as reported by CRCon. ApiPrefix is an alternative for installations serving each game
+	// server under a path of the base URL. All are empty for the default server of the installation.
+	ServerNumber int    `json:"server_number,omitempty"`
+	InstanceUrl  string `json:"instance_url,omitempty"`
+	ApiPrefix    string `json:"api_prefix,omitempty"`
+
+	PermissionStatus *PermissionStatus `json:"permission_status"`
+	Health           CredentialsHealth `json:"health"`
+}
+
+// ApiUrl returns the URL of the targeted game server.
+func (c CRConCredentials) ApiUrl() string {
+	if c.InstanceUrl != "" {
+		return c.InstanceUrl
+	}
+	if c.ApiPrefix != "" {
+		if u, err := url.JoinPath(c.BaseUrl, c.ApiPrefix); err == nil {
+			return u
+		}
+	}
+	return c.BaseUrl
+}
+
+// Instance describes the targeted game server for display.
+func (c CRConCredentials) Instance() string {
+	if c.ServerNumber > 0 {
+		return fmt.Sprintf("Server #%d", c.ServerNumber)
+	}
+	if c.ApiPrefix != "" {
+		return "Prefix " + c.ApiPrefix
+	}
+	return "Default server"
+}
+
+// SameTarget tells whether both credentials are used for the same CRCon installation. A stored API key must only be
+// reused for the same target, otherwise it would be sent to a URL it was not created for.
+func (c CRConCredentials) SameTarget(o CRConCredentials) bool {
+	return c.BaseUrl == o.BaseUrl
 }
 
-type TCAdminCredentials struct {
-	BaseUrl   string `json:"base_url"`
-	ServiceId string `json:"service_id"`
-	Username  string `json:"username"`
-	Password  string `json:"password"`
+func (c CRConCredentials) Validate() error {
+	u, err := url.Parse(c.BaseUrl)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("the URL must start with http:// or https://")
+	}
+	if c.ServerNumber < 0 {
+		return errors.New("the server number must be positive")
+	}
+	if c.ServerNumber > 0 && c.ApiPrefix != "" {
+		return errors.New("either a server number or an API prefix can be set, not both")
+	}
+	if c.ApiPrefix != "" && !apiPrefixPattern.MatchString(c.ApiPrefix) {
+		return errors.New("the API prefix must be a path like /server2")
+	}
+	return nil
+}
+
+// RConCredentials grant direct access to the RCON interface of the game server, for servers managed without CRCon.
+type RConCredentials struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Password string `json:"password"`
+
+	Health CredentialsHealth `json:"health"`
+}
+
+func (c RConCredentials) Address() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
+
+// SameTarget tells whether both credentials log in to the same RCON interface, see CRConCredentials.SameTarget.
+func (c RConCredentials) SameTarget(o RConCredentials) bool {
+	return c.Address() == o.Address()
+}
+
+func (c RConCredentials) Validate() error {
+	if c.Host == "" || strings.ContainsAny(c.Host, "/:@ ") && net.ParseIP(c.Host) == nil {
+		return errors.New("the host must be a host name or IP address without protocol and port")
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		return errors.New("the port must be a number between 1 and 65535")
+	}
+	if c.Password == "" {
+		return errors.New("the RCON password is required")
+	}
+	return nil
 }
 
 type ServerUpdate struct {
@@ -35,3 +182,27 @@ type ServerUpdate struct {
 func (s ServerUpdate) RequiresRestart() bool {
 	return s.ServerName != "" || s.ServerPassword != ""
 }
+
+// PermissionStatus is the result of comparing the permissions of a CRCon API key with the permissions the bot needs.
+type PermissionStatus struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Superuser bool      `json:"superuser"`
+	Ok        []string  `json:"ok"`
+	Missing   []string  `json:"missing"`
+	Extra     []string  `json:"extra"`
+}
+
+// LeastPrivilege is true when the API key grants exactly the required permissions.
+func (p PermissionStatus) LeastPrivilege() bool {
+	return !p.Superuser && len(p.Missing) == 0 && len(p.Extra) == 0
+}
+
+// CredentialsHealth records the outcome of the periodic verification of credentials.
+type CredentialsHealth struct {
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+}
+
+func (h CredentialsHealth) Failing() bool {
+	return h.LastError != ""
+}
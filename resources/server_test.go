@@ -0,0 +1,132 @@
+package resources_test
+
+import (
+	"github.com/floriansw/hll-discord-server-watcher/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Server", func() {
+	Describe("CRConCredentials", func() {
+		valid := resources.CRConCredentials{
+			BaseUrl: "https://crcon.example.com",
+			ApiKey:  "key",
+		}
+
+		It("targets the base URL by default", func() {
+			Expect(valid.Validate()).ToNot(HaveOccurred())
+			Expect(valid.ApiUrl()).To(Equal("https://crcon.example.com"))
+			Expect(valid.Instance()).To(Equal("Default server"))
+		})
+
+		It("targets the resolved URL of a server number", func() {
+			c := valid
+			c.ServerNumber = 2
+			c.InstanceUrl = "https://crcon.example.com:8011"
+			Expect(c.Validate()).ToNot(HaveOccurred())
+			Expect(c.ApiUrl()).To(Equal("https://crcon.example.com:8011"))
+			Expect(c.Instance()).To(Equal("Server #2"))
+		})
+
+		It("appends the API prefix to the base URL", func() {
+			c := valid
+			c.ApiPrefix = "/server2"
+			Expect(c.Validate()).ToNot(HaveOccurred())
+			Expect(c.ApiUrl()).To(Equal("https://crcon.example.com/server2"))
+		})
+
+		It("rejects a server number together with an API prefix", func() {
+			c := valid
+			c.ServerNumber = 2
+			c.ApiPrefix = "/server2"
+			Expect(c.Validate()).To(HaveOccurred())
+		})
+
+		It("rejects an API prefix which is not a path", func() {
+			c := valid
+			c.ApiPrefix = "/../admin?x=1"
+			Expect(c.Validate()).To(HaveOccurred())
+		})
+
+		It("only has the same target for the same base URL", func() {
+			c := valid
+			c.ApiKey = ""
+			c.ApiPrefix = "/server2"
+			Expect(c.SameTarget(valid)).To(BeTrue())
+			c.BaseUrl = "https://attacker.example.com"
+			Expect(c.SameTarget(valid)).To(BeFalse())
+		})
+	})
+
+	Describe("RConCredentials", func() {
+		valid := resources.RConCredentials{
+			Host:     "203.0.113.10",
+			Port:     7779,
+			Password: "secret",
+		}
+
+		It("accepts valid credentials", func() {
+			Expect(valid.Validate()).ToNot(HaveOccurred())
+			Expect(valid.Address()).To(Equal("203.0.113.10:7779"))
+		})
+
+		It("accepts IPv6 addresses", func() {
+			c := valid
+			c.Host = "2001:db8::1"
+			Expect(c.Validate()).ToNot(HaveOccurred())
+			Expect(c.Address()).To(Equal("[2001:db8::1]:7779"))
+		})
+
+		It("rejects a host with protocol", func() {
+			c := valid
+			c.Host = "tcp://203.0.113.10"
+			Expect(c.Validate()).To(HaveOccurred())
+		})
+
+		It("rejects an invalid port", func() {
+			c := valid
+			c.Port = 70000
+			Expect(c.Validate()).To(HaveOccurred())
+		})
+
+		It("only has the same target for the same host and port", func() {
+			c := valid
+			c.Password = ""
+			Expect(c.SameTarget(valid)).To(BeTrue())
+			c.Port = 7780
+			Expect(c.SameTarget(valid)).To(BeFalse())
+			c = valid
+			c.Host = "198.51.100.1"
+			Expect(c.SameTarget(valid)).To(BeFalse())
+		})
+	})
+
+	Describe("Summary", func() {
+		It("names the running template", func() {
+			s := resources.Server{
+				Name:            "Event",
+				RConCredentials: &resources.RConCredentials{Host: "203.0.113.10", Port: 7779},
+				AppliedTemplate: &resources.AppliedTemplate{Values: resources.Template{Name: "Warfare"}},
+			}
+			Expect(s.Summary().Description).To(Equal("Templates only, no hosting credentials, running Warfare"))
+		})
+
+		It("indexes the applied template and the credentials", func() {
+			s := resources.Server{
+				ServerId:        "a-server",
+				Name:            "Event",
+				RConCredentials: &resources.RConCredentials{Host: "203.0.113.10", Port: 7779},
+				AppliedTemplate: &resources.AppliedTemplate{TemplateId: "a-template"},
+			}
+			summary := s.Summary()
+			Expect(summary.TemplateId).To(Equal("a-template"))
+			Expect(summary.Manageable).To(BeTrue())
+			Expect(summary.Credentials).To(BeTrue())
+
+			summary = resources.Server{PterodactylCredentials: &resources.PterodactylCredentials{}}.Summary()
+			Expect(summary.TemplateId).To(BeEmpty())
+			Expect(summary.Manageable).To(BeFalse())
+			Expect(summary.Credentials).To(BeTrue())
+		})
+	})
+})
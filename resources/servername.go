@@ -0,0 +1,126 @@
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxServerNameLength is the longest server name Hell Let Loose accepts.
+const MaxServerNameLength = 90
+
+// ServerNamePlaceholders are the placeholders every server name template can use, besides the name variables of the
+// server.
+var ServerNamePlaceholders = []string{"server", "index", "date", "weekday", "event"}
+
+var (
+	placeholderPattern  = regexp.MustCompile(`\{([^{}]*)}`)
+	variableNamePattern = regexp.MustCompile(`^[a-z0-9_-]+$`)
+)
+
+// RenderServerName replaces the {placeholders} of a server name template with the values of vars. Placeholders
+// without a value are kept as they are and returned as missing.
+func RenderServerName(template string, vars map[string]string) (name string, missing []string) {
+	name = placeholderPattern.ReplaceAllStringFunc(template, func(p string) string {
+		key := strings.ToLower(p[1 : len(p)-1])
+		if v, ok := vars[key]; ok {
+			return v
+		}
+		if !slices.Contains(missing, key) {
+			missing = append(missing, key)
+		}
+		return p
+	})
+	return
+}
+
+// ValidateServerName checks that the rendered name can be set on the server.
+func ValidateServerName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("the server name must not be empty")
+	}
+	if l := utf8.RuneCountInString(name); l > MaxServerNameLength {
+		return fmt.Errorf("the server name must not be longer than %d characters, got %d", MaxServerNameLength, l)
+	}
+	return nil
+}
+
+// validateServerNameTemplate checks the syntax of the placeholders. Templates without placeholders are checked like
+// rendered names, as they are applied unchanged.
+func validateServerNameTemplate(template string) error {
+	for _, m := range placeholderPattern.FindAllStringSubmatch(template, -1) {
+		if !variableNamePattern.MatchString(strings.ToLower(m[1])) {
+			return fmt.Errorf("invalid placeholder %s in server name template, only letters, digits, - and _ are allowed", m[0])
+		}
+	}
+	if !placeholderPattern.MatchString(template) {
+		return ValidateServerName(template)
+	}
+	return nil
+}
+
+// ServerNameValues returns the values of the placeholders of a server name template applied to the server at the
+// given time. event is the name of the applied template. The name variables of the server take precedence over the
+// built-in placeholders, e.g. to set the {index} of servers without a CRCon server number.
+func (s Server) ServerNameValues(event string, at time.Time) map[string]string {
+	index := 1
+	if s.CRConCredentials != nil && s.CRConCredentials.ServerNumber > 0 {
+		index = s.CRConCredentials.ServerNumber
+	}
+	vars := map[string]string{
+		"server":  s.Name,
+		"index":   strconv.Itoa(index),
+		"date":    at.Format(time.DateOnly),
+		"weekday": at.Weekday().String(),
+		"event":   event,
+	}
+	for k, v := range s.NameVariables {
+		vars[k] = v
+	}
+	return vars
+}
+
+// NameTemplateFrom turns the current name of the server into a server name template by replacing the name of the
+// server with {server}, so that the template can be applied to other servers. It returns false when the current name
+// does not contain the name of the server, or contains braces which would be read as placeholders.
+func (s Server) NameTemplateFrom(current string) (string, bool) {
+	if s.Name == "" || !strings.Contains(current, s.Name) || strings.ContainsAny(current, "{}") {
+		return "", false
+	}
+	return strings.ReplaceAll(current, s.Name, "{server}"), true
+}
+
+// ParseNameVariables parses name variables given as one key=value pair per line.
+func ParseNameVariables(v string) (map[string]string, error) {
+	vars := map[string]string{}
+	for _, line := range strings.Split(v, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !ok || !variableNamePattern.MatchString(key) {
+			return nil, fmt.Errorf("invalid variable %q, use one key=value pair per line, keys may only contain letters, digits, - and _", strings.TrimSpace(line))
+		}
+		vars[key] = strings.TrimSpace(value)
+	}
+	if len(vars) == 0 {
+		return nil, nil
+	}
+	return vars, nil
+}
+
+// FormatNameVariables formats name variables as one key=value pair per line, sorted by key.
+func FormatNameVariables(vars map[string]string) string {
+	var lines []string
+	for k, v := range vars {
+		lines = append(lines, k+"="+v)
+	}
+	slices.Sort(lines)
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,74 @@
+package resources_test
+
+import (
+	"github.com/floriansw/hll-discord-server-watcher/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"strings"
+	"time"
+)
+
+var _ = Describe("ServerName", func() {
+	// a Saturday
+	at := time.Date(2026, 10, 17, 20, 0, 0, 0, time.UTC)
+	server := resources.Server{
+		Name:             "Main",
+		CRConCredentials: &resources.CRConCredentials{ServerNumber: 2},
+		NameVariables:    map[string]string{"region": "EU"},
+	}
+
+	It("renders the built-in placeholders and variables of the server", func() {
+		name, missing := resources.RenderServerName("[{region}] {server} #{index} | {event} {weekday} {date}", server.ServerNameValues("Seeding", at))
+		Expect(missing).To(BeEmpty())
+		Expect(name).To(Equal("[EU] Main #2 | Seeding Saturday 2026-10-17"))
+	})
+
+	It("prefers the variables of the server over built-in placeholders", func() {
+		s := server
+		s.NameVariables = map[string]string{"index": "7"}
+		name, _ := resources.RenderServerName("{server} #{INDEX}", s.ServerNameValues("Seeding", at))
+		Expect(name).To(Equal("Main #7"))
+	})
+
+	It("keeps unknown placeholders and reports them", func() {
+		name, missing := resources.RenderServerName("{server} {clan} {clan}", server.ServerNameValues("Seeding", at))
+		Expect(name).To(Equal("Main {clan} {clan}"))
+		Expect(missing).To(Equal([]string{"clan"}))
+	})
+
+	It("rejects names longer than the limit of the game", func() {
+		Expect(resources.ValidateServerName(strings.Repeat("a", resources.MaxServerNameLength))).ToNot(HaveOccurred())
+		Expect(resources.ValidateServerName(strings.Repeat("a", resources.MaxServerNameLength+1))).To(HaveOccurred())
+		Expect(resources.ValidateServerName(" ")).To(HaveOccurred())
+	})
+
+	It("validates the placeholders of a template", func() {
+		t := resources.Template{Name: "Seeding", ServerNameTemplate: "{server} {not valid}"}
+		Expect(t.Validate()).To(HaveOccurred())
+		t.ServerNameTemplate = "{server} {region}"
+		Expect(t.Validate()).ToNot(HaveOccurred())
+		t.ServerNameTemplate = strings.Repeat("a", resources.MaxServerNameLength+1)
+		Expect(t.Validate()).To(HaveOccurred())
+	})
+
+	It("turns the current name into a template", func() {
+		tpl, ok := server.NameTemplateFrom("[EU] Main | Seeding")
+		Expect(ok).To(BeTrue())
+		Expect(tpl).To(Equal("[EU] {server} | Seeding"))
+
+		_, ok = server.NameTemplateFrom("Other server")
+		Expect(ok).To(BeFalse())
+		_, ok = server.NameTemplateFrom("Main {clan}")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("parses name variables", func() {
+		vars, err := resources.ParseNameVariables("Region = EU\n\nclan=ABC=1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(vars).To(Equal(map[string]string{"region": "EU", "clan": "ABC=1"}))
+		Expect(resources.FormatNameVariables(vars)).To(Equal("clan=ABC=1\nregion=EU"))
+
+		_, err = resources.ParseNameVariables("no value")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,140 @@
+package resources
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The names of the settings of a template, as shown in drift reports and the template embed.
+const (
+	SettingServerName           = "Server name"
+	SettingWelcomeMessage       = "Welcome message"
+	SettingBroadcastMessages    = "Broadcast messages"
+	SettingProfanityFilter      = "Profanity filter"
+	SettingTeamSwitchCooldown   = "Team switch cooldown"
+	SettingAutoBalanceThreshold = "Autobalance threshold"
+	SettingAutoBalanceEnabled   = "Autobalance enabled"
+	SettingIdleAutoKickTime     = "Idle autokick time"
+	SettingMaxPingAutoKick      = "Max ping autokick"
+	SettingQueueLength          = "Queue length"
+	SettingVipSlots             = "VIP slots"
+	SettingVoteKickEnabled      = "Vote kick enabled"
+	SettingMapRotation          = "Map rotation"
+)
+
+type setting struct {
+	name string
+	// value formats the setting of the template for comparison, ok is false when the template leaves it unchanged.
+	value func(t Template) (v string, ok bool)
+	// empty is true when the template does not set the setting, it is then inherited from the parent template.
+	empty func(t Template) bool
+	// adopt copies the setting from another template.
+	adopt func(t *Template, from Template)
+}
+
+var settings = []setting{{
+	name:  SettingServerName,
+	value: func(t Template) (string, bool) { return t.ServerNameTemplate, t.ServerNameTemplate != "" },
+	empty: func(t Template) bool { return t.ServerNameTemplate == "" },
+	adopt: func(t *Template, from Template) { t.ServerNameTemplate = from.ServerNameTemplate },
+}, {
+	name:  SettingWelcomeMessage,
+	value: func(t Template) (string, bool) { return t.WelcomeMessage, true },
+	empty: func(t Template) bool { return t.WelcomeMessage == "" },
+	adopt: func(t *Template, from Template) { t.WelcomeMessage = from.WelcomeMessage },
+}, {
+	name: SettingBroadcastMessages,
+	value: func(t Template) (string, bool) {
+		var lines []string
+		for _, m := range t.BroadcastMessage {
+			lines = append(lines, fmt.Sprintf("%ds: %s", m.Time, m.Message))
+		}
+		return strings.Join(lines, "\n"), true
+	},
+	empty: func(t Template) bool { return len(t.BroadcastMessage) == 0 },
+	adopt: func(t *Template, from Template) { t.BroadcastMessage = slices.Clone(from.BroadcastMessage) },
+}, {
+	name: SettingProfanityFilter,
+	value: func(t Template) (string, bool) {
+		p := slices.Clone(t.ProfanityFilter)
+		slices.Sort(p)
+		return strings.Join(p, ", "), true
+	},
+	// the profanity modal stores a single empty line when it is cleared
+	empty: func(t Template) bool {
+		return !slices.ContainsFunc(t.ProfanityFilter, func(p string) bool { return strings.TrimSpace(p) != "" })
+	},
+	adopt: func(t *Template, from Template) { t.ProfanityFilter = slices.Clone(from.ProfanityFilter) },
+}, {
+	name:  SettingTeamSwitchCooldown,
+	value: func(t Template) (string, bool) { return optionalInt(t.TeamSwitchCooldown) },
+	empty: func(t Template) bool { return t.TeamSwitchCooldown == nil },
+	adopt: func(t *Template, from Template) { t.TeamSwitchCooldown = from.TeamSwitchCooldown },
+}, {
+	name:  SettingAutoBalanceThreshold,
+	value: func(t Template) (string, bool) { return optionalInt(t.AutoBalanceThreshold) },
+	empty: func(t Template) bool { return t.AutoBalanceThreshold == nil },
+	adopt: func(t *Template, from Template) { t.AutoBalanceThreshold = from.AutoBalanceThreshold },
+}, {
+	name:  SettingAutoBalanceEnabled,
+	value: func(t Template) (string, bool) { return optionalBool(t.AutoBalanceEnabled) },
+	empty: func(t Template) bool { return t.AutoBalanceEnabled == nil },
+	adopt: func(t *Template, from Template) { t.AutoBalanceEnabled = from.AutoBalanceEnabled },
+}, {
+	name:  SettingIdleAutoKickTime,
+	value: func(t Template) (string, bool) { return optionalInt(t.IdleAutoKickTime) },
+	empty: func(t Template) bool { return t.IdleAutoKickTime == nil },
+	adopt: func(t *Template, from Template) { t.IdleAutoKickTime = from.IdleAutoKickTime },
+}, {
+	name:  SettingMaxPingAutoKick,
+	value: func(t Template) (string, bool) { return optionalInt(t.MaxPingAutoKick) },
+	empty: func(t Template) bool { return t.MaxPingAutoKick == nil },
+	adopt: func(t *Template, from Template) { t.MaxPingAutoKick = from.MaxPingAutoKick },
+}, {
+	name:  SettingQueueLength,
+	value: func(t Template) (string, bool) { return optionalInt(t.QueueLength) },
+	empty: func(t Template) bool { return t.QueueLength == nil },
+	adopt: func(t *Template, from Template) { t.QueueLength = from.QueueLength },
+}, {
+	name:  SettingVipSlots,
+	value: func(t Template) (string, bool) { return optionalInt(t.VipSlots) },
+	empty: func(t Template) bool { return t.VipSlots == nil },
+	adopt: func(t *Template, from Template) { t.VipSlots = from.VipSlots },
+}, {
+	name:  SettingVoteKickEnabled,
+	value: func(t Template) (string, bool) { return optionalBool(t.VoteKickEnabled) },
+	empty: func(t Template) bool { return t.VoteKickEnabled == nil },
+	adopt: func(t *Template, from Template) { t.VoteKickEnabled = from.VoteKickEnabled },
+}, {
+	name: SettingMapRotation,
+	value: func(t Template) (string, bool) {
+		var ids []string
+		for _, m := range t.MapRotation {
+			ids = append(ids, m.Id)
+		}
+		return strings.Join(ids, ", "), len(ids) != 0
+	},
+	// the rotation, starting map and game mode belong together and are only inherited as a whole
+	empty: func(t Template) bool { return len(t.MapRotation) == 0 && t.GameMode == "" },
+	adopt: func(t *Template, from Template) {
+		t.MapRotation = slices.Clone(from.MapRotation)
+		t.StartingMap = from.StartingMap
+		t.GameMode = from.GameMode
+	},
+}}
+
+func optionalInt(i *int) (string, bool) {
+	if i == nil {
+		return "", false
+	}
+	return strconv.Itoa(*i), true
+}
+
+func optionalBool(b *bool) (string, bool) {
+	if b == nil {
+		return "", false
+	}
+	return strconv.FormatBool(*b), true
+}
@@ -1,22 +1,39 @@
 package resources
 
 import (
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"os"
 	"path"
+	"sync"
 )
 
 type fileBackedStore[T Identifiable] struct {
 	directory string
+
+	mu sync.Mutex
+	// written holds the hash of the content of each file this store saved last, nil for files it deleted.
+	written map[Id]*[sha256.Size]byte
 }
 
 type Identifiable interface {
 	Id() string
 }
 
-func (m *fileBackedStore[T]) Find(matchId string) (res *T, err error) {
-	b, err := os.ReadFile(path.Join(m.directory, matchId))
+func (m *fileBackedStore[T]) path(id Id) (string, error) {
+	if id.v == "" {
+		return "", &InvalidIdError{}
+	}
+	return path.Join(m.directory, id.v), nil
+}
+
+func (m *fileBackedStore[T]) Find(matchId Id) (res *T, err error) {
+	p, err := m.path(matchId)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(p)
 	if errors.Is(err, os.ErrNotExist) {
 		return nil, nil
 	} else if err != nil {
@@ -26,7 +43,7 @@ func (m *fileBackedStore[T]) Find(matchId string) (res *T, err error) {
 	return res, err
 }
 
-func (m *fileBackedStore[T]) List() (result []string, error error) {
+func (m *fileBackedStore[T]) List() (result []Id, error error) {
 	b, err := os.ReadDir(m.directory)
 	if err != nil {
 		return nil, err
@@ -35,23 +52,76 @@ func (m *fileBackedStore[T]) List() (result []string, error error) {
 		if entry.IsDir() {
 			continue
 		}
-		result = append(result, entry.Name())
+		id, err := ParseId(entry.Name())
+		if err != nil {
+			continue
+		}
+		result = append(result, id)
 	}
 	return result, err
 }
 
 func (m *fileBackedStore[T]) Save(entity T) error {
+	id, err := ParseId(entity.Id())
+	if err != nil {
+		return err
+	}
+	p, err := m.path(id)
+	if err != nil {
+		return err
+	}
 	d, err := json.Marshal(entity)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path.Join(m.directory, entity.Id()), d, 0644)
+	if err := os.WriteFile(p, d, 0644); err != nil {
+		return err
+	}
+	h := sha256.Sum256(d)
+	m.remember(id, &h)
+	return nil
 }
 
-func (m *fileBackedStore[T]) Delete(id string) error {
-	err := os.Remove(path.Join(m.directory, id))
+func (m *fileBackedStore[T]) Delete(id Id) error {
+	p, err := m.path(id)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(p)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	m.remember(id, nil)
+	return nil
+}
+
+// Written is true when the file of the entity has the content this store saved last, or is missing after the store
+// deleted it. It tells changes of the bot itself apart from changes made by someone else.
+func (m *fileBackedStore[T]) Written(id Id) bool {
+	m.mu.Lock()
+	want, ok := m.written[id]
+	m.mu.Unlock()
+	if !ok {
+		return false
+	}
+	p, err := m.path(id)
+	if err != nil {
+		return false
+	}
+	b, err := os.ReadFile(p)
 	if errors.Is(err, os.ErrNotExist) {
-		return nil
+		return want == nil
+	} else if err != nil || want == nil {
+		return false
+	}
+	return sha256.Sum256(b) == *want
+}
+
+func (m *fileBackedStore[T]) remember(id Id, h *[sha256.Size]byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.written == nil {
+		m.written = map[Id]*[sha256.Size]byte{}
 	}
-	return err
+	m.written[id] = h
 }
@@ -0,0 +1,81 @@
+package resources_test
+
+import (
+	"errors"
+	"github.com/floriansw/hll-discord-server-watcher/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"os"
+	"path"
+)
+
+var _ = Describe("Storage", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp(os.TempDir(), "storage")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.MkdirAll(path.Join(dir, "templates"), 0755)).ToNot(HaveOccurred())
+		Expect(os.WriteFile(path.Join(dir, "config.json"), []byte("{}"), 0644)).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).ToNot(HaveOccurred())
+	})
+
+	It("rejects IDs escaping the store directory", func() {
+		s := resources.NewTemplates(path.Join(dir, "templates"))
+		var ide *resources.InvalidIdError
+
+		_, err := resources.ParseId("../config.json")
+		Expect(errors.As(err, &ide)).To(BeTrue())
+		_, err = s.Find(resources.Id{})
+		Expect(errors.As(err, &ide)).To(BeTrue())
+		Expect(errors.As(s.Delete(resources.Id{}), &ide)).To(BeTrue())
+		Expect(errors.As(s.Save(resources.Template{TemplateId: "../config.json"}), &ide)).To(BeTrue())
+		Expect(path.Join(dir, "config.json")).To(BeAnExistingFile())
+	})
+
+	It("lists only files with valid IDs", func() {
+		s := resources.NewTemplates(path.Join(dir, "templates"))
+		Expect(s.Save(resources.Template{TemplateId: "5f0c6d3e-3f2a-4c43-9a43-0b8c1c6c4d1e", Name: "A"})).ToNot(HaveOccurred())
+		Expect(os.WriteFile(path.Join(dir, "templates", ".swap.json"), []byte("{}"), 0644)).ToNot(HaveOccurred())
+
+		Expect(s.List()).To(ConsistOf(mustParseId("5f0c6d3e-3f2a-4c43-9a43-0b8c1c6c4d1e")))
+	})
+
+	It("tells whether a file was written by the store", func() {
+		s := resources.NewTemplates(path.Join(dir, "templates"))
+		a := mustParseId("a")
+		Expect(s.Written(a)).To(BeFalse())
+		Expect(s.Save(resources.Template{TemplateId: "a", Name: "A"})).ToNot(HaveOccurred())
+		Expect(s.Written(a)).To(BeTrue())
+
+		Expect(os.WriteFile(path.Join(dir, "templates", "a"), []byte(`{"id":"a","name":"B"}`), 0644)).ToNot(HaveOccurred())
+		Expect(s.Written(a)).To(BeFalse())
+
+		Expect(s.Delete(a)).ToNot(HaveOccurred())
+		Expect(s.Written(a)).To(BeTrue())
+	})
+})
+
+var _ = Describe("ParseId", func() {
+	It("accepts UUIDs and slugs", func() {
+		Expect(resources.ParseId("5f0c6d3e-3f2a-4c43-9a43-0b8c1c6c4d1e")).To(WithTransform(resources.Id.String, Equal("5f0c6d3e-3f2a-4c43-9a43-0b8c1c6c4d1e")))
+		Expect(resources.ParseId("event_template-1")).To(WithTransform(resources.Id.String, Equal("event_template-1")))
+	})
+
+	It("rejects anything else", func() {
+		for _, id := range []string{"", "../config.json", "a/b", ".hidden", "with space"} {
+			_, err := resources.ParseId(id)
+			Expect(err).To(HaveOccurred(), id)
+		}
+	})
+})
+
+func mustParseId(v string) resources.Id {
+	id, err := resources.ParseId(v)
+	Expect(err).ToNot(HaveOccurred())
+	return id
+}
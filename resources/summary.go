@@ -0,0 +1,21 @@
+package resources
+
+// Summary holds the fields of a resource which are needed to list it, e.g. in select menus or autocomplete choices.
+type Summary struct {
+	Id          string
+	Name        string
+	Description string
+
+	// The following fields allow to filter entities without reading each of them. TemplateId is the template applied
+	// to a server, ParentId the parent of a template.
+	TemplateId string
+	ParentId   string
+	// Manageable is true for servers with CRCon or RCON credentials, Credentials for servers with any credentials.
+	Manageable  bool
+	Credentials bool
+}
+
+type Summarizable interface {
+	Identifiable
+	Summary() Summary
+}
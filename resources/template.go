@@ -1,21 +1,240 @@
 package resources
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/floriansw/go-crcon"
+	"slices"
+	"strconv"
+	"strings"
+)
+
 type Template struct {
-	TemplateId           string             `json:"id"`
-	Name                 string             `json:"name"`
-	TeamSwitchCooldown   int                `json:"team_switch_cooldown"`
-	AutoBalanceThreshold int                `json:"auto_balance_threshold"`
-	ServerNameTemplate   string             `json:"server_name_template"`
-	WelcomeMessage       string             `json:"welcome_message"`
-	BroadcastMessage     []BroadcastMessage `json:"broadcast_message"`
-	ProfanityFilter      []string           `json:"profanity_filter"`
+	TemplateId string `json:"id"`
+	Name       string `json:"name"`
+	// Version is incremented with every change of the template, to tell whether a server runs the latest values.
+	Version int `json:"version,omitempty"`
+	// ParentId is the template the settings this template leaves empty are inherited from.
+	ParentId           string             `json:"parent_id,omitempty"`
+	ServerNameTemplate string             `json:"server_name_template"`
+	WelcomeMessage     string             `json:"welcome_message"`
+	BroadcastMessage   []BroadcastMessage `json:"broadcast_message"`
+	ProfanityFilter    []string           `json:"profanity_filter"`
+
+	// The following settings are left unchanged on the server when not set.
+	TeamSwitchCooldown   *int  `json:"team_switch_cooldown,omitempty"`
+	AutoBalanceThreshold *int  `json:"auto_balance_threshold,omitempty"`
+	AutoBalanceEnabled   *bool `json:"auto_balance_enabled,omitempty"`
+	IdleAutoKickTime     *int  `json:"idle_auto_kick_time,omitempty"`
+	MaxPingAutoKick      *int  `json:"max_ping_auto_kick,omitempty"`
+	QueueLength          *int  `json:"queue_length,omitempty"`
+	VipSlots             *int  `json:"vip_slots,omitempty"`
+	VoteKickEnabled      *bool `json:"vote_kick_enabled,omitempty"`
+
+	// MapRotation replaces the rotation of the server when not empty, the server switches to StartingMap if set.
+	MapRotation []Map  `json:"map_rotation,omitempty"`
+	StartingMap string `json:"starting_map,omitempty"`
+	// GameMode restricts the rotation to maps of the game mode. Without a MapRotation, all maps of the game mode the
+	// server can play are used.
+	GameMode crcon.GameMode `json:"game_mode,omitempty"`
 }
 
+// templateFormat is the version of the JSON format templates are stored in. Templates without a format stored a team
+// switch cooldown and autobalance threshold of 0 when they did not set them, which inherited the setting from the
+// parent template, or did not override it for the overrides of a server. Only templates without a parent applied it.
+const templateFormat = 1
+
+func (t Template) MarshalJSON() ([]byte, error) {
+	type plain Template
+	return json.Marshal(struct {
+		plain
+		Format int `json:"format"`
+	}{plain(t), templateFormat})
+}
+
+func (t *Template) UnmarshalJSON(b []byte) error {
+	type plain Template
+	v := struct {
+		*plain
+		Format int `json:"format"`
+	}{plain: (*plain)(t)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	// the overrides of a server are the only templates without an ID
+	if v.Format < 1 && (t.ParentId != "" || t.TemplateId == "") {
+		if t.TeamSwitchCooldown != nil && *t.TeamSwitchCooldown == 0 {
+			t.TeamSwitchCooldown = nil
+		}
+		if t.AutoBalanceThreshold != nil && *t.AutoBalanceThreshold == 0 {
+			t.AutoBalanceThreshold = nil
+		}
+	}
+	return nil
+}
+
+// GameModes are the game modes a template can be restricted to.
+var GameModes = []crcon.GameMode{crcon.GameModeWarfare, crcon.GameModeOffensive, crcon.GameModeSkirmish}
+
+// MaxQueueLength is the maximum number of players Hell Let Loose allows in the queue.
+const MaxQueueLength = 6
+
 func (t Template) Id() string {
 	return t.TemplateId
 }
 
+func (t Template) Summary() Summary {
+	return Summary{Id: t.TemplateId, Name: t.Name, ParentId: t.ParentId}
+}
+
+// Validate checks that the template only holds values which can be applied to a server. It is meant to be used for
+// templates which were not created through the bot, e.g. imported ones.
+func (t Template) Validate() error {
+	if t.TemplateId != "" {
+		if _, err := ParseId(t.TemplateId); err != nil {
+			return err
+		}
+	}
+	if t.Name == "" {
+		return errors.New("template name must not be empty")
+	}
+	if t.ParentId != "" {
+		if _, err := ParseId(t.ParentId); err != nil {
+			return fmt.Errorf("invalid parent template: %w", err)
+		}
+		if t.ParentId == t.TemplateId {
+			return errors.New("a template can not inherit from itself")
+		}
+	}
+	if t.TeamSwitchCooldown != nil && *t.TeamSwitchCooldown < 0 {
+		return fmt.Errorf("team switch cooldown must not be negative, got %d", *t.TeamSwitchCooldown)
+	}
+	if t.AutoBalanceThreshold != nil && *t.AutoBalanceThreshold < 0 {
+		return fmt.Errorf("autobalance threshold must not be negative, got %d", *t.AutoBalanceThreshold)
+	}
+	if t.ServerNameTemplate != "" {
+		if err := validateServerNameTemplate(t.ServerNameTemplate); err != nil {
+			return err
+		}
+	}
+	if t.IdleAutoKickTime != nil && *t.IdleAutoKickTime < 0 {
+		return fmt.Errorf("idle autokick time must not be negative, got %d", *t.IdleAutoKickTime)
+	}
+	if t.MaxPingAutoKick != nil && *t.MaxPingAutoKick < 0 {
+		return fmt.Errorf("max ping autokick must not be negative, got %d", *t.MaxPingAutoKick)
+	}
+	if t.QueueLength != nil && (*t.QueueLength < 0 || *t.QueueLength > MaxQueueLength) {
+		return fmt.Errorf("queue length must be between 0 and %d, got %d", MaxQueueLength, *t.QueueLength)
+	}
+	if t.VipSlots != nil && *t.VipSlots < 0 {
+		return fmt.Errorf("VIP slots must not be negative, got %d", *t.VipSlots)
+	}
+	for idx, m := range t.MapRotation {
+		if m.Id == "" {
+			return fmt.Errorf("map %d of the rotation must have an ID", idx)
+		}
+	}
+	if t.GameMode != "" && !slices.Contains(GameModes, t.GameMode) {
+		return fmt.Errorf("unknown game mode %s", t.GameMode)
+	}
+	var rotation []Map
+	if len(t.MapRotation) != 0 {
+		var err error
+		if rotation, err = t.Rotation(nil); err != nil {
+			return err
+		}
+	}
+	if t.StartingMap != "" && !slices.ContainsFunc(rotation, func(m Map) bool { return m.Id == t.StartingMap }) {
+		return fmt.Errorf("starting map %s must be part of the map rotation", t.StartingMap)
+	}
+	for idx, m := range t.BroadcastMessage {
+		if m.Time <= 0 {
+			return fmt.Errorf("broadcast message %d must have a positive time, got %d", idx, m.Time)
+		}
+		if m.Message == "" {
+			return fmt.Errorf("broadcast message %d must not be empty", idx)
+		}
+	}
+	return nil
+}
+
+// Rotation returns the maps the rotation of a server is set to. The maps of the template, or the available maps of the
+// server when the template has none, are filtered by the game mode. An empty rotation without an error keeps the
+// rotation of the server.
+func (t Template) Rotation(available []Map) ([]Map, error) {
+	rotation := t.MapRotation
+	if len(rotation) == 0 && t.GameMode != "" {
+		rotation = available
+	}
+	if t.GameMode == "" {
+		return rotation, nil
+	}
+	var res []Map
+	for _, m := range rotation {
+		if m.GameMode == string(t.GameMode) {
+			res = append(res, m)
+		}
+	}
+	if len(res) == 0 {
+		return nil, fmt.Errorf("the map rotation does not contain any map of game mode %s", t.GameMode)
+	}
+	return res, nil
+}
+
 type BroadcastMessage struct {
 	Time    int    `json:"time"`
 	Message string `json:"message"`
 }
+
+// ParseBroadcastMessages parses broadcast messages given as one "seconds: message" pair per line, e.g.
+// "300: Join our Discord".
+func ParseBroadcastMessages(v string) ([]BroadcastMessage, error) {
+	var res []BroadcastMessage
+	for _, line := range strings.Split(v, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		t, message, ok := strings.Cut(line, ":")
+		seconds, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(t), "s"))
+		if !ok || err != nil {
+			return nil, fmt.Errorf("invalid broadcast message %q, use one seconds: message pair per line", strings.TrimSpace(line))
+		}
+		res = append(res, BroadcastMessage{Time: seconds, Message: strings.TrimSpace(message)})
+	}
+	return res, nil
+}
+
+// FormatBroadcastMessages formats broadcast messages as one "seconds: message" pair per line.
+func FormatBroadcastMessages(messages []BroadcastMessage) string {
+	var lines []string
+	for _, m := range messages {
+		lines = append(lines, fmt.Sprintf("%d: %s", m.Time, m.Message))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Map is a map of the game in a game mode and environment, e.g. Foy Warfare at night.
+type Map struct {
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	GameMode    string `json:"game_mode"`
+	Environment string `json:"environment"`
+}
+
+func (m Map) String() string {
+	return fmt.Sprintf("%s (%s, %s)", m.Name, m.GameMode, m.Environment)
+}
+
+// ResolveMaps returns the maps with the given IDs out of the available ones, in the order of the IDs.
+func ResolveMaps(ids []string, available []Map) ([]Map, error) {
+	var res []Map
+	for _, id := range ids {
+		idx := slices.IndexFunc(available, func(m Map) bool { return m.Id == id })
+		if idx == -1 {
+			return nil, fmt.Errorf("unknown map %s", id)
+		}
+		res = append(res, available[idx])
+	}
+	return res, nil
+}
@@ -0,0 +1,142 @@
+package resources_test
+
+import (
+	"encoding/json"
+	"github.com/floriansw/go-crcon"
+	"github.com/floriansw/hll-discord-server-watcher/resources"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Template", func() {
+	Describe("Validate", func() {
+		It("accepts a minimal template", func() {
+			Expect(resources.Template{Name: "Event"}.Validate()).ToNot(HaveOccurred())
+		})
+
+		It("rejects a template without name", func() {
+			Expect(resources.Template{}.Validate()).To(HaveOccurred())
+		})
+
+		It("rejects negative thresholds", func() {
+			negative := -1
+			Expect(resources.Template{Name: "Event", AutoBalanceThreshold: &negative}.Validate()).To(HaveOccurred())
+			Expect(resources.Template{Name: "Event", TeamSwitchCooldown: &negative}.Validate()).To(HaveOccurred())
+		})
+
+		It("rejects out of range server settings", func() {
+			seven, negative := 7, -1
+			Expect(resources.Template{Name: "Event", QueueLength: &seven}.Validate()).To(HaveOccurred())
+			Expect(resources.Template{Name: "Event", VipSlots: &negative}.Validate()).To(HaveOccurred())
+			Expect(resources.Template{Name: "Event", IdleAutoKickTime: &negative}.Validate()).To(HaveOccurred())
+		})
+
+		It("rejects a starting map outside of the rotation", func() {
+			t := resources.Template{Name: "Event", MapRotation: []resources.Map{{Id: "foy_warfare"}}, StartingMap: "kursk_warfare"}
+			Expect(t.Validate()).To(HaveOccurred())
+
+			t.StartingMap = "foy_warfare"
+			Expect(t.Validate()).ToNot(HaveOccurred())
+		})
+
+		It("rejects a game mode without maps in the rotation", func() {
+			t := resources.Template{Name: "Event", GameMode: crcon.GameModeSkirmish, MapRotation: []resources.Map{{Id: "foy_warfare", GameMode: "warfare"}}}
+			Expect(t.Validate()).To(HaveOccurred())
+
+			t.MapRotation = append(t.MapRotation, resources.Map{Id: "foy_skirmish", GameMode: "skirmish"})
+			Expect(t.Validate()).ToNot(HaveOccurred())
+		})
+
+		It("rejects unknown game modes", func() {
+			Expect(resources.Template{Name: "Event", GameMode: "conquest"}.Validate()).To(HaveOccurred())
+		})
+
+		It("rejects invalid broadcast messages", func() {
+			Expect(resources.Template{Name: "Event", BroadcastMessage: []resources.BroadcastMessage{{Time: 0, Message: "Hi"}}}.Validate()).To(HaveOccurred())
+			Expect(resources.Template{Name: "Event", BroadcastMessage: []resources.BroadcastMessage{{Time: 10}}}.Validate()).To(HaveOccurred())
+		})
+	})
+
+	Describe("Rotation", func() {
+		available := []resources.Map{
+			{Id: "foy_warfare", GameMode: "warfare"},
+			{Id: "foy_skirmish", GameMode: "skirmish"},
+			{Id: "kursk_skirmish", GameMode: "skirmish"},
+		}
+
+		It("keeps the rotation of the server without maps and game mode", func() {
+			r, err := resources.Template{}.Rotation(available)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(r).To(BeEmpty())
+		})
+
+		It("uses the available maps of the game mode without maps", func() {
+			r, err := resources.Template{GameMode: crcon.GameModeSkirmish}.Rotation(available)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(r).To(Equal(available[1:]))
+		})
+
+		It("filters the maps of the template by the game mode", func() {
+			r, err := resources.Template{GameMode: crcon.GameModeWarfare, MapRotation: available[:2]}.Rotation(available)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(r).To(Equal(available[:1]))
+		})
+
+		It("fails when no map matches the game mode", func() {
+			_, err := resources.Template{GameMode: crcon.GameModeOffensive}.Rotation(available)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("JSON", func() {
+		It("migrates thresholds of 0 which were inherited by templates of the old format", func() {
+			var t resources.Template
+			Expect(json.Unmarshal([]byte(`{"name":"Event","parent_id":"base","team_switch_cooldown":0,"auto_balance_threshold":3}`), &t)).To(Succeed())
+			Expect(t.TeamSwitchCooldown).To(BeNil())
+			Expect(*t.AutoBalanceThreshold).To(Equal(3))
+
+			var root resources.Template
+			Expect(json.Unmarshal([]byte(`{"id":"event","name":"Event","team_switch_cooldown":0}`), &root)).To(Succeed())
+			Expect(*root.TeamSwitchCooldown).To(Equal(0))
+
+			var overrides resources.Template
+			Expect(json.Unmarshal([]byte(`{"name":"","team_switch_cooldown":0}`), &overrides)).To(Succeed())
+			Expect(overrides.TeamSwitchCooldown).To(BeNil())
+		})
+
+		It("keeps thresholds of 0 set in the current format", func() {
+			zero := 0
+			b, err := json.Marshal(resources.Template{Name: "Event", ParentId: "base", TeamSwitchCooldown: &zero})
+			Expect(err).ToNot(HaveOccurred())
+			var t resources.Template
+			Expect(json.Unmarshal(b, &t)).To(Succeed())
+			Expect(t.TeamSwitchCooldown).To(Equal(&zero))
+			Expect(t.AutoBalanceThreshold).To(BeNil())
+		})
+	})
+
+	Describe("BroadcastMessages", func() {
+		It("parses one message per line", func() {
+			messages, err := resources.ParseBroadcastMessages("300: Join our Discord: discord.gg/example\n\n60s: Seeding")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(messages).To(Equal([]resources.BroadcastMessage{{Time: 300, Message: "Join our Discord: discord.gg/example"}, {Time: 60, Message: "Seeding"}}))
+			Expect(resources.FormatBroadcastMessages(messages)).To(Equal("300: Join our Discord: discord.gg/example\n60: Seeding"))
+
+			_, err = resources.ParseBroadcastMessages("Join our Discord")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("ResolveMaps", func() {
+		available := []resources.Map{{Id: "foy_warfare", GameMode: "warfare"}, {Id: "kursk_offensive_ger", GameMode: "offensive"}}
+
+		It("returns the maps in the given order", func() {
+			Expect(resources.ResolveMaps([]string{"kursk_offensive_ger", "foy_warfare"}, available)).To(Equal([]resources.Map{available[1], available[0]}))
+		})
+
+		It("rejects unknown maps", func() {
+			_, err := resources.ResolveMaps([]string{"foy_warfare", "unknown"}, available)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})